@@ -0,0 +1,158 @@
+package grepo
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Limit appends "limit :limit offset :offset" to sql and returns it together
+// with a copy of args holding both values, ready for MapRowsN:
+//
+//	query, args := grepo.Limit(base, args, 10, 20)
+//	artists, err := repo.MapRowsN(ctx, query, args, mapFunc)
+//
+// The args passed in are not modified, any existing limit or offset keys in
+// the copy are replaced.
+func Limit(sql string, args map[string]any, limit int, offset int) (string, map[string]any) {
+	merged := make(map[string]any, len(args)+2)
+	maps.Copy(merged, args)
+	merged["limit"] = limit
+	merged["offset"] = offset
+
+	return sql + " limit :limit offset :offset", merged
+}
+
+// OrderBy returns the "order by" fragment for a user supplied sort, such as the
+// query parameters of a sortable list endpoint, mapping field to the column
+// allowed holds for it so nothing the user sent is interpolated:
+//
+//	order, err := grepo.OrderBy(q.Get("sort"), q.Get("dir"), map[string]string{
+//		"title":  "Title",
+//		"artist": "ArtistId",
+//	})
+//	query := "select AlbumId, Title from Album " + order
+//
+// The columns in allowed are trusted and used as they are, so they may be
+// expressions such as lower(Title). dir must be asc or desc, in any case, and
+// defaults to asc when empty. An unknown field or direction is an error.
+func OrderBy(field, dir string, allowed map[string]string) (string, error) {
+	col, ok := allowed[field]
+	if !ok {
+		return "", fmt.Errorf("cannot sort by '%s', want one of %v", field, slices.Sorted(maps.Keys(allowed)))
+	}
+
+	switch strings.ToLower(dir) {
+	case "", "asc":
+		dir = "asc"
+	case "desc":
+		dir = "desc"
+	default:
+		return "", fmt.Errorf("invalid sort direction '%s', want asc or desc", dir)
+	}
+
+	return "order by " + col + " " + dir, nil
+}
+
+// Where accumulates the conditions of a dynamically built filter, such as
+// optional search terms, each with its own named parameters. The zero value is
+// an empty filter:
+//
+//	var w grepo.Where
+//	if name != "" {
+//		w.And("Name like :name", map[string]any{"name": name + "%"})
+//	}
+//	if artistId > 0 {
+//		w.And("ArtistId = :id", map[string]any{"id": artistId})
+//	}
+//	query, args := w.Apply("select AlbumId, Title from Album", nil)
+//
+// The parameters of each condition are renamed with a prefix numbering the
+// condition, :name in the first becomes :w1_name, so conditions which happen to
+// use the same names do not collide. Every condition is parenthesised and they
+// are combined left to right, so a.And(b).Or(c) filters on (a and b) or c.
+type Where struct {
+	clause string
+	args   map[string]any
+	n      int
+}
+
+// And adds condition, which must hold as well as those before it.
+func (w *Where) And(condition string, args map[string]any) *Where {
+	return w.add("and", condition, args)
+}
+
+// Or adds condition, as an alternative to those before it.
+func (w *Where) Or(condition string, args map[string]any) *Where {
+	return w.add("or", condition, args)
+}
+
+func (w *Where) add(op string, condition string, args map[string]any) *Where {
+	w.n++
+	if w.args == nil {
+		w.args = make(map[string]any)
+	}
+
+	prefix := "w" + strconv.Itoa(w.n) + "_"
+	condition = re.ReplaceAllStringFunc(condition, func(token string) string {
+		if isCast(token) {
+			return token
+		}
+		w.args[prefix+token[1:]] = lookupArg(args, token)
+		return ":" + prefix + token[1:]
+	})
+
+	if w.clause == "" {
+		w.clause = "(" + condition + ")"
+	} else {
+		w.clause += " " + op + " (" + condition + ")"
+	}
+
+	return w
+}
+
+// Clause returns the combined conditions and their renamed args, or an empty
+// string when there are none.
+func (w *Where) Clause() (string, map[string]any) {
+	return w.clause, maps.Clone(w.args)
+}
+
+// Apply appends " where " and the combined conditions to sql and returns it
+// together with a copy of args holding their values, ready for MapRowsN. With
+// no conditions sql is returned as is. The args passed in are not modified.
+func (w *Where) Apply(sql string, args map[string]any) (string, map[string]any) {
+	merged := make(map[string]any, len(args)+len(w.args))
+	maps.Copy(merged, args)
+	maps.Copy(merged, w.args)
+
+	if w.clause == "" {
+		return sql, merged
+	}
+
+	return sql + " where " + w.clause, merged
+}
+
+// Ident validates name as a table or column name and returns it quoted for the
+// repository's dialect, ready to be concatenated into a query, for the parts
+// of a dynamic query which cannot be bound as parameters such as a sortable
+// column:
+//
+//	col, err := repo.Ident(r.URL.Query().Get("sort"), "Title", "AlbumId")
+//	query := "select AlbumId, Title from Album order by " + col
+//
+// When allowed is given name must be one of them, otherwise it must be a plain
+// identifier, optionally qualified by a schema, so anything carrying quotes,
+// whitespace or punctuation is refused rather than interpolated.
+func (repo repository[T]) Ident(name string, allowed ...string) (string, error) {
+	if len(allowed) > 0 && !slices.Contains(allowed, name) {
+		return "", fmt.Errorf("identifier '%s' is not one of %v", name, allowed)
+	}
+
+	if err := checkIdent(name); err != nil {
+		return "", err
+	}
+
+	return repo.dialect.QuoteIdent(name), nil
+}
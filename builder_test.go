@@ -0,0 +1,122 @@
+package grepo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLimit(t *testing.T) {
+	args := map[string]any{"maxId": 10}
+
+	query, merged := Limit("select AlbumId from Album where AlbumId < :maxId order by AlbumId", args, 3, 2)
+
+	want := "select AlbumId from Album where AlbumId < :maxId order by AlbumId limit :limit offset :offset"
+	if query != want {
+		t.Errorf("want `%s` got `%s`", want, query)
+	}
+
+	if len(merged) != 3 || merged["limit"] != 3 || merged["offset"] != 2 || merged["maxId"] != 10 {
+		t.Errorf("want maxId, limit and offset in the args got %v", merged)
+	}
+
+	if len(args) != 1 {
+		t.Errorf("want the original args untouched got %v", args)
+	}
+
+	results, err := albums.MapRowsN(context.Background(), query, merged, albumIdMapper)
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(results) != 3 || results[0].AlbumID != 3 {
+		t.Errorf("want albums 3 to 5 got %d rows", len(results))
+	}
+}
+
+func TestWhere(t *testing.T) {
+	var w Where
+	w.And("ArtistId = :id", map[string]any{"id": 1})
+	w.And("Title like :title", map[string]any{"title": "F%"})
+	w.Or("AlbumId = :id", map[string]any{"id": 2})
+
+	query, args := w.Apply("select AlbumId from Album", nil)
+
+	want := "select AlbumId from Album where (ArtistId = :w1_id) and (Title like :w2_title) or (AlbumId = :w3_id)"
+	if query != want {
+		t.Errorf("want `%s` got `%s`", want, query)
+	}
+
+	if len(args) != 3 || args["w1_id"] != 1 || args["w2_title"] != "F%" || args["w3_id"] != 2 {
+		t.Errorf("want w1_id, w2_title and w3_id in the args got %v", args)
+	}
+
+	results, err := albums.MapRowsN(context.Background(), query+" order by AlbumId", args, albumIdMapper)
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(results) != 2 || results[0].AlbumID != 1 || results[1].AlbumID != 2 {
+		t.Errorf("want albums 1 and 2 got %d rows", len(results))
+	}
+
+	var empty Where
+	if query, _ := empty.Apply("select AlbumId from Album", nil); query != "select AlbumId from Album" {
+		t.Errorf("want the query untouched without conditions got `%s`", query)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	allowed := map[string]string{"title": "Title", "id": "AlbumId"}
+
+	order, err := OrderBy("id", "DESC", allowed)
+	if err != nil || order != "order by AlbumId desc" {
+		t.Fatalf("want `order by AlbumId desc` got `%s` and %v", order, err)
+	}
+
+	results, err := albums.MapRows(context.Background(), "select AlbumId from Album "+order+" limit 1", nil, albumIdMapper)
+	if err != nil || len(results) != 1 || results[0].AlbumID != 347 {
+		t.Errorf("want album 347 first got %v and %v", results, err)
+	}
+
+	if order, _ = OrderBy("title", "", allowed); order != "order by Title asc" {
+		t.Errorf("want asc by default got `%s`", order)
+	}
+
+	if _, err = OrderBy("Title; drop table Album", "asc", allowed); err == nil {
+		t.Errorf("want error for a field outside allowed got nil")
+	}
+
+	if _, err = OrderBy("title", "asc; drop table Album", allowed); err == nil {
+		t.Errorf("want error for an invalid direction got nil")
+	}
+}
+
+func albumIdMapper(r *RowMap) (*Album, error) {
+	return &Album{AlbumID: r.Int64("AlbumId")}, r.Err()
+}
+
+func TestIdent(t *testing.T) {
+	col, err := albums.Ident("Title", "Title", "AlbumId")
+	if err != nil || col != `"Title"` {
+		t.Fatalf("want \"Title\" got %s and %v", col, err)
+	}
+
+	results, err := albums.MapRows(context.Background(), "select AlbumId, Title, ArtistId from Album order by "+col+" limit 1", nil, albumIdMapper)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("want 1 row ordered by %s got %d and %v", col, len(results), err)
+	}
+
+	for _, name := range []string{`Title; drop table Album`, `Title" desc, "AlbumId`, `1=1`, ``} {
+		if _, err := albums.Ident(name); err == nil {
+			t.Errorf("want error for identifier %q got nil", name)
+		}
+	}
+
+	if _, err := albums.Ident("ArtistId", "Title", "AlbumId"); err == nil {
+		t.Errorf("want error for an identifier outside allowed got nil")
+	}
+
+	if col, _ := NewRepository[Album](testDB, WithDialect(MySQLDialect{})).Ident("Album.Title"); col != "`Album`.`Title`" {
+		t.Errorf("want mysql quoting got %s", col)
+	}
+}
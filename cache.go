@@ -0,0 +1,118 @@
+package grepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// resultCache holds the results of MapRowsCached keyed by the query and its
+// arguments. It is shared by every copy of a repository.
+type resultCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry[T]
+}
+
+type cacheEntry[T any] struct {
+	results []*T
+	expires time.Time
+}
+
+func newResultCache[T any]() *resultCache[T] {
+	return &resultCache[T]{
+		entries: make(map[string]cacheEntry[T]),
+	}
+}
+
+func cacheKey(sql string, args []any) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%#v", sql, args)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *resultCache[T]) get(key string, now time.Time) ([]*T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if now.After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return copyResults(entry.results), true
+}
+
+func (c *resultCache[T]) put(key string, results []*T, ttl time.Duration, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// sweep anything expired so queries with ever changing args do not pile up
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = cacheEntry[T]{
+		results: copyResults(results),
+		expires: now.Add(ttl),
+	}
+}
+
+// copyResults makes a shallow copy of every result so neither the cache nor
+// its callers can mutate what the other holds. Fields that are themselves
+// references (slices, maps, pointers) are still shared.
+func copyResults[T any](results []*T) []*T {
+	if results == nil {
+		return nil
+	}
+
+	copied := make([]*T, len(results))
+	for i, r := range results {
+		if r != nil {
+			c := *r
+			copied[i] = &c
+		}
+	}
+	return copied
+}
+
+// MapRowsCached behaves like MapRows, but keeps the results for ttl and serves
+// identical queries (same SQL and args) from the cache until they expire. The
+// mapper is not part of the cache key, so use a single mapper per query. The
+// cache is bypassed within a transaction.
+func (repo repository[T]) MapRowsCached(
+	ctx context.Context,
+	ttl time.Duration,
+	sql string,
+	args []any,
+	mapFunc MapFunc[T]) ([]*T, error) {
+
+	if repo.tx != nil || repo.cache == nil {
+		return repo.MapRows(ctx, sql, args, mapFunc)
+	}
+
+	key := cacheKey(sql, args)
+
+	if results, ok := repo.cache.get(key, time.Now()); ok {
+		slog.Debug("MapRowsCached served %d row(s) from the cache", "grepo", len(results))
+		return results, nil
+	}
+
+	results, err := repo.MapRows(ctx, sql, args, mapFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.cache.put(key, results, ttl, time.Now())
+
+	return results, nil
+}
@@ -0,0 +1,71 @@
+package grepo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMapRowsCached(t *testing.T) {
+	repo := NewRepository[Album](testDB)
+	mapped := 0
+
+	mapper := func(r *RowMap) (*Album, error) {
+		mapped++
+		return &Album{
+			AlbumID:  r.Int64("AlbumId"),
+			Title:    r.String("Title"),
+			ArtistID: r.Int32("ArtistId"),
+		}, r.Err()
+	}
+
+	query := "select AlbumId, Title, ArtistId from Album where AlbumId < $1"
+
+	first, err := repo.MapRowsCached(context.Background(), time.Minute, query, []any{4}, mapper)
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if mapped != 3 {
+		t.Fatalf("want 3 rows mapped got %d", mapped)
+	}
+
+	// mutating a result must not change what the cache holds
+	first[0].Title = "mutated"
+
+	second, err := repo.MapRowsCached(context.Background(), time.Minute, query, []any{4}, mapper)
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if mapped != 3 {
+		t.Errorf("want the second call served from the cache, got %d rows mapped", mapped)
+	}
+
+	if second[0].Title == "mutated" {
+		t.Errorf("want the cached copy unaffected by callers")
+	}
+
+	if _, err = repo.MapRowsCached(context.Background(), time.Minute, query, []any{3}, mapper); err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if mapped != 5 {
+		t.Errorf("want different args to hit the database, got %d rows mapped", mapped)
+	}
+}
+
+func TestResultCacheExpires(t *testing.T) {
+	c := newResultCache[Album]()
+	now := time.Now()
+
+	c.put("key", []*Album{{AlbumID: 1}}, time.Second, now)
+
+	if _, ok := c.get("key", now.Add(500*time.Millisecond)); !ok {
+		t.Errorf("want a hit within the ttl")
+	}
+
+	if _, ok := c.get("key", now.Add(2*time.Second)); ok {
+		t.Errorf("want a miss after the ttl")
+	}
+}
@@ -0,0 +1,47 @@
+package grepo
+
+import (
+	"context"
+)
+
+// MapRowsChan executes the query in the background and sends each mapped row
+// over the returned row channel as soon as it is read, so a consumer can
+// process a large result without holding all of it. When the rows are
+// exhausted, the mapper fails or ctx is cancelled both channels are closed and
+// the statement is released. The error channel receives at most one error,
+// and is closed without one when every row was delivered. A consumer which
+// stops reading early must cancel ctx so the query can be released.
+func (repo repository[T]) MapRowsChan(
+	ctx context.Context,
+	sql string,
+	args []any,
+	mapFunc MapFunc[T]) (<-chan *T, <-chan error) {
+
+	out := make(chan *T)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(out)
+
+		err := repo.eachRow(ctx, sql, args, func(rowMap *RowMap) error {
+			r, err := repo.mapRow(mapFunc, rowMap)
+			if err != nil {
+				return err
+			}
+
+			select {
+			case out <- r:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return out, errs
+}
@@ -0,0 +1,49 @@
+package grepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMapRowsChan(t *testing.T) {
+	rows, errs := albums.MapRowsChan(context.Background(), "select AlbumId, Title, ArtistId from Album", nil, albumMapper)
+
+	count := 0
+	for range rows {
+		count++
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if count != 347 {
+		t.Errorf("want 347 rows got %d", count)
+	}
+}
+
+func TestMapRowsChanCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rows, errs := albums.MapRowsChan(ctx, "select AlbumId, Title, ArtistId from Album", nil, albumMapper)
+
+	if _, ok := <-rows; !ok {
+		t.Fatalf("want a row before cancelling")
+	}
+	cancel()
+
+	count := 1
+	for range rows {
+		count++
+	}
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("want context canceled got %v", err)
+	}
+
+	if count >= 347 {
+		t.Errorf("want cancellation to stop production got all %d rows", count)
+	}
+}
@@ -0,0 +1,71 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// Strings executes a query returning a single column and returns its values,
+// such as every artist name, without writing a mapper. A NULL value is an
+// error, coalesce a nullable column in the query.
+func (repo repository[T]) Strings(ctx context.Context, query string, args []any) ([]string, error) {
+	return column[string](ctx, repo, query, args)
+}
+
+// Column executes a query returning a single column on repo and scans each
+// value into a V using the conversions of rows.Scan, so V may be any type
+// Scan accepts, such as int64, time.Time or sql.NullString. A query returning
+// more than one column is an error.
+func Column[V, T any](ctx context.Context, repo Repository[T], query string, args []any) ([]V, error) {
+	switch r := repo.(type) {
+	case *repository[T]:
+		return column[V](ctx, *r, query, args)
+	case repository[T]:
+		return column[V](ctx, r, query, args)
+	default:
+		return nil, fmt.Errorf("func Column() requires a repository created by NewRepository, got %T", repo)
+	}
+}
+
+func column[V, T any](ctx context.Context, repo repository[T], query string, args []any) ([]V, error) {
+	var values []V
+
+	err := repo.queryRows(ctx, query, args, func(rows *sql.Rows) error {
+		defer func() {
+			if err := rows.Close(); err != nil {
+				slog.Error("error closing rows %w", "err", err)
+			}
+		}()
+
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+
+		if len(cols) != 1 {
+			return fmt.Errorf("func Column() requires a single column, the query returned %d %v", len(cols), cols)
+		}
+
+		for rows.Next() {
+			if repo.options.maxRows > 0 && len(values) >= repo.options.maxRows {
+				return fmt.Errorf("%w, the limit is %d", ErrTooManyRows, repo.options.maxRows)
+			}
+
+			var v V
+			if err = rows.Scan(&v); err != nil {
+				return fmt.Errorf("func Column() failed to scan row %d: %w", len(values), err)
+			}
+			values = append(values, v)
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
@@ -0,0 +1,59 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
+// ColumnInfo describes a column of a result set.
+type ColumnInfo struct {
+	// Name is the column name as returned by the query
+	Name string
+	// DatabaseType is the database's name for the column type, such as INTEGER
+	// or VARCHAR, empty when the driver does not report it
+	DatabaseType string
+	// Nullable reports whether the column may be NULL, only meaningful when
+	// NullableKnown is set
+	Nullable bool
+	// NullableKnown is set when the driver reports nullability
+	NullableKnown bool
+}
+
+// Columns executes the query and returns a description of its result columns
+// without reading any rows, for generic tooling such as dynamic UIs. The type
+// name and nullability are filled in where the driver supports them.
+func (repo repository[T]) Columns(ctx context.Context, query string, args []any) ([]ColumnInfo, error) {
+	var columns []ColumnInfo
+
+	err := repo.queryRows(ctx, query, args, func(rows *sql.Rows) error {
+		defer func() {
+			if err := rows.Close(); err != nil {
+				slog.Error("error closing rows %w", "err", err)
+			}
+		}()
+
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			return err
+		}
+
+		columns = make([]ColumnInfo, len(types))
+		for i, ct := range types {
+			nullable, ok := ct.Nullable()
+			columns[i] = ColumnInfo{
+				Name:          ct.Name(),
+				DatabaseType:  ct.DatabaseTypeName(),
+				Nullable:      nullable,
+				NullableKnown: ok,
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,61 @@
+package grepo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestColumns(t *testing.T) {
+	columns, err := albums.Columns(context.Background(), "select AlbumId, Title, ArtistId from Album where AlbumId = $1", []any{1})
+	if err != nil {
+		t.Fatalf("failed to describe columns %v", err)
+	}
+
+	want := []ColumnInfo{
+		{Name: "AlbumId", DatabaseType: "INTEGER"},
+		{Name: "Title", DatabaseType: "NVARCHAR(160)"},
+		{Name: "ArtistId", DatabaseType: "INTEGER"},
+	}
+
+	if len(columns) != len(want) {
+		t.Fatalf("want %d columns got %d", len(want), len(columns))
+	}
+
+	for i, c := range columns {
+		if c.Name != want[i].Name || c.DatabaseType != want[i].DatabaseType {
+			t.Errorf("want %+v got %+v", want[i], c)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	names, err := albums.Strings(context.Background(), "select Name from Artist order by ArtistId", nil)
+	if err != nil {
+		t.Fatalf("failed to read artist names %v", err)
+	}
+
+	var count int
+	if err = testDB.QueryRow("select count(*) from Artist").Scan(&count); err != nil {
+		t.Fatalf("failed to count artists %v", err)
+	}
+
+	if len(names) != count || names[0] != "AC/DC" {
+		t.Errorf("want %d names starting with AC/DC got %d %v", count, len(names), names[:1])
+	}
+
+	if _, err = albums.Strings(context.Background(), "select ArtistId, Name from Artist", nil); err == nil {
+		t.Errorf("want error reading two columns got nil")
+	}
+}
+
+func TestColumn(t *testing.T) {
+	ids, err := Column[int64](context.Background(), albums, "select AlbumId from Album where ArtistId = $1 order by AlbumId", []any{1})
+	if err != nil {
+		t.Fatalf("failed to read album ids %v", err)
+	}
+
+	if !reflect.DeepEqual(ids, []int64{1, 4}) {
+		t.Errorf("want album ids [1 4] got %v", ids)
+	}
+}
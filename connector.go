@@ -0,0 +1,258 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Connector interface {
+	GetConnection() (*sql.DB, error)
+}
+
+// isClosed reports whether db has been closed, without a round trip to the
+// server. database/sql refuses a closed handle before it looks at the context,
+// so a ping with a context which is already cancelled fails straight away
+// with "sql: database is closed" for a closed handle and with the context's
+// error for an open one.
+func isClosed(db *sql.DB) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := db.PingContext(ctx)
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// connectorKeys are the keys NewConnector accepts for each provider, besides
+// provider itself.
+var connectorKeys = map[string][]string{
+	"postgres": {"host", "port", "user", "password", "db"},
+	"mysql":    {"host", "port", "user", "password", "db"},
+	"sqlite3":  {"path", "wal", "busy_timeout", "foreign_keys", "temp_copy"},
+}
+
+// NewConnector builds the connector for the provider key of cfg, for
+// connection settings read from configuration rather than code:
+//
+//   - postgres reads host, port (default 5432), user, password and db into a
+//     PostgresConnector.
+//   - sqlite3 reads path, which may be :memory:, and the optional wal,
+//     foreign_keys and temp_copy flags, parsed by strconv.ParseBool, and a
+//     busy_timeout duration such as 5s into a SQLiteConnector.
+//   - mysql reads host, port (default 3306), user, password and db into a
+//     DSNConnector for the go-sql-driver/mysql driver, which must be imported.
+//
+// A key the provider does not use is an error, so a typo is not silently
+// ignored.
+func NewConnector(cfg map[string]string) (Connector, error) {
+	provider := cfg["provider"]
+
+	known, ok := connectorKeys[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q, want one of %v", provider, slices.Sorted(maps.Keys(connectorKeys)))
+	}
+
+	for key := range cfg {
+		if key != "provider" && !slices.Contains(known, key) {
+			return nil, fmt.Errorf("unknown %s connector key %q, want one of %v", provider, key, known)
+		}
+	}
+
+	// the typed constructors are checked before returning, a nil pointer in
+	// the Connector interface would not compare equal to nil
+	switch provider {
+	case "sqlite3":
+		c, err := sqliteFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	case "mysql":
+		port, err := configPort(cfg, 3306)
+		if err != nil {
+			return nil, err
+		}
+		c, err := NewConnectorFromDSN("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+			cfg["user"], cfg["password"], cfg["host"], port, cfg["db"]))
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	default:
+		port, err := configPort(cfg, 5432)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresConnector(Database{
+			Host:     cfg["host"],
+			Port:     port,
+			User:     cfg["user"],
+			Password: cfg["password"],
+			Provider: provider,
+			Db:       cfg["db"],
+		}), nil
+	}
+}
+
+// configPort reads the port key of cfg, def when it is not set.
+func configPort(cfg map[string]string, def int) (int, error) {
+	if cfg["port"] == "" {
+		return def, nil
+	}
+
+	port, err := strconv.Atoi(cfg["port"])
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q: %w", cfg["port"], err)
+	}
+	return port, nil
+}
+
+// sqliteFromConfig builds a SQLiteConnector from the sqlite3 keys of cfg.
+func sqliteFromConfig(cfg map[string]string) (*SQLiteConnector, error) {
+	if cfg["path"] == "" {
+		return nil, fmt.Errorf("the sqlite3 connector requires a path")
+	}
+
+	var opts []SQLiteOption
+
+	flags := []struct {
+		key    string
+		option SQLiteOption
+	}{
+		{"wal", WithWAL()},
+		{"foreign_keys", WithForeignKeys()},
+		{"temp_copy", WithTempCopy()},
+	}
+
+	for _, flag := range flags {
+		if cfg[flag.key] == "" {
+			continue
+		}
+		on, err := strconv.ParseBool(cfg[flag.key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", flag.key, cfg[flag.key], err)
+		}
+		if on {
+			opts = append(opts, flag.option)
+		}
+	}
+
+	if cfg["busy_timeout"] != "" {
+		d, err := time.ParseDuration(cfg["busy_timeout"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid busy_timeout %q: %w", cfg["busy_timeout"], err)
+		}
+		opts = append(opts, WithBusyTimeout(d))
+	}
+
+	return NewSQLiteConnector(cfg["path"], opts...)
+}
+
+// DSNConnector opens a database with any registered driver and a fully custom
+// connection string, for options (TLS parameters, search_path, etc.) the typed
+// connectors do not model.
+type DSNConnector struct {
+	driver string
+	dsn    string
+	db     *sql.DB
+	mu     sync.Mutex
+}
+
+// NewConnectorFromDSN returns a connector which opens dsn with the named driver.
+// An error is returned when no driver of that name is registered.
+func NewConnectorFromDSN(driver, dsn string) (*DSNConnector, error) {
+	if err := checkDriver(driver); err != nil {
+		return nil, err
+	}
+
+	return &DSNConnector{
+		driver: driver,
+		dsn:    dsn,
+	}, nil
+}
+
+// driverImports are the packages which register the commonly used drivers.
+var driverImports = map[string]string{
+	"postgres": "github.com/lib/pq",
+	"pgx":      "github.com/jackc/pgx/v5/stdlib",
+	"sqlite3":  "github.com/mattn/go-sqlite3",
+	"mysql":    "github.com/go-sql-driver/mysql",
+}
+
+// checkDriver returns a descriptive error when driver is not registered with
+// database/sql, which otherwise only surfaces as "unknown driver" on first use.
+func checkDriver(driver string) error {
+	if slices.Contains(sql.Drivers(), driver) {
+		return nil
+	}
+
+	if pkg, ok := driverImports[driver]; ok {
+		return fmt.Errorf("database driver %q is not registered, add the import _ %q", driver, pkg)
+	}
+	return fmt.Errorf("database driver %q is not registered, import the package which provides it, registered drivers are %v",
+		driver, sql.Drivers())
+}
+
+// GetConnection opens the database on first use and returns the same handle on
+// subsequent calls. Like sql.Open, no connection is made until it is needed.
+func (c *DSNConnector) GetConnection() (*sql.DB, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db != nil {
+		return c.db, nil
+	}
+
+	db, err := sql.Open(c.driver, c.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", redactError(err, ""))
+	}
+
+	c.db = db
+	return db, nil
+}
+
+func (c *DSNConnector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db != nil {
+		err := c.db.Close()
+		c.db = nil
+		return err
+	}
+	return nil
+}
+
+// DBConnector adapts an existing *sql.DB, such as one configured by a framework
+// or pool manager, to the Connector interface. The caller keeps ownership of the
+// handle's lifecycle.
+type DBConnector struct {
+	db *sql.DB
+}
+
+// NewConnectorFromDB returns a connector which always hands out db.
+func NewConnectorFromDB(db *sql.DB) *DBConnector {
+	return &DBConnector{
+		db: db,
+	}
+}
+
+func (c *DBConnector) GetConnection() (*sql.DB, error) {
+	if c.db == nil {
+		return nil, fmt.Errorf("no database was provided to the connector")
+	}
+	return c.db, nil
+}
+
+// Close is a no-op, the wrapped *sql.DB is not owned by the connector and must
+// be closed by whoever opened it.
+func (c *DBConnector) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package grepo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// converters holds the functions registered with RegisterConverter, keyed by
+// the type they convert to.
+type converters map[reflect.Type]func(any) (any, error)
+
+// RegisterConverter is an Option which registers fn as the way Convert reads a
+// column into T, for domain types such as money or coordinates which none of
+// the RowMap accessors produce:
+//
+//	repo := grepo.NewRepository[Invoice](db, grepo.RegisterConverter(parseMoney))
+//
+// fn is given the value as the driver returned it. Registering a second
+// converter for the same T replaces the first.
+func RegisterConverter[T any](fn func(any) (T, error)) Option {
+	return func(o *options) {
+		if o.converters == nil {
+			o.converters = make(converters)
+		}
+		o.converters[reflect.TypeFor[T]()] = func(v any) (any, error) {
+			return fn(v)
+		}
+	}
+}
+
+// Convert reads the value within the RowMap with the provided key (k) as T
+// using the converter registered for T with RegisterConverter. A ColReadError
+// is recorded when the converter fails, and an error when none is registered.
+// SQL NULL returns the zero T without calling the converter.
+func Convert[T any](m *RowMap, k string) T {
+	var zero T
+
+	val, err := m.try(k)
+	if err != nil {
+		m.addErr(err)
+		return zero
+	}
+
+	if val == nil {
+		return zero
+	}
+
+	target := reflect.TypeFor[T]()
+	fn, ok := m.converters[target]
+	if !ok {
+		m.addErr(fmt.Errorf("no converter is registered for %v to read key '%s', see RegisterConverter", target, k))
+		return zero
+	}
+
+	v, err := fn(val)
+	if err != nil {
+		m.addErr(fmt.Errorf("%w: %w", NewColReadError(k, val, target.String()), err))
+		return zero
+	}
+
+	return v.(T)
+}
@@ -0,0 +1,44 @@
+package grepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Cents is a price held as a whole number of cents.
+type Cents int64
+
+func parseCents(v any) (Cents, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("want a float64 price got %T", v)
+	}
+	return Cents(math.Round(f * 100)), nil
+}
+
+func TestConvert(t *testing.T) {
+	prices := NewRepository[Album](testDB, RegisterConverter(parseCents))
+
+	rows, err := prices.Query(context.Background(), "select UnitPrice, Name from Track where TrackId = $1", []any{63})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("want 1 row got %d and %v", len(rows), err)
+	}
+	r := rows[0]
+
+	if price := Convert[Cents](r, "UnitPrice"); price != 99 || r.Err() != nil {
+		t.Errorf("want 99 cents got %d and %v", price, r.Err())
+	}
+
+	var colErr ColReadError
+	if Convert[Cents](r, "Name"); !errors.As(r.Err(), &colErr) {
+		t.Errorf("want a ColReadError when the converter fails got %v", r.Err())
+	}
+
+	r.ResetErrors()
+	if Convert[Status](r, "UnitPrice"); r.Err() == nil {
+		t.Errorf("want an error without a registered converter got nil")
+	}
+}
@@ -0,0 +1,447 @@
+package grepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// identRe matches the table and column names accepted by the generated SQL
+// helpers, optionally qualified by a schema. Identifiers cannot be bound as
+// parameters, so anything else is refused rather than interpolated into the
+// query, and those accepted are quoted so reserved words can be used.
+var identRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+func checkIdent(names ...string) error {
+	for _, name := range names {
+		if !identRe.MatchString(name) {
+			return fmt.Errorf("invalid identifier '%s'", name)
+		}
+	}
+	return nil
+}
+
+// checkUnqualified is checkIdent for a name which cannot be qualified by a
+// schema, such as a savepoint.
+func checkUnqualified(name string) error {
+	if strings.Contains(name, ".") {
+		return fmt.Errorf("invalid identifier '%s', it cannot be qualified", name)
+	}
+	return checkIdent(name)
+}
+
+// FindByIDs loads the rows of table whose idCol is one of ids. When
+// preserveOrder is set the results are returned in the order of ids, otherwise
+// in whatever order the database returns them.
+func (repo repository[T]) FindByIDs(
+	ctx context.Context,
+	table string,
+	idCol string,
+	ids []any,
+	mapFunc MapFunc[T],
+	preserveOrder bool) ([]*T, error) {
+
+	if err := checkIdent(table, idCol); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf("select * from %s where %s in ( :ids )", repo.dialect.QuoteIdent(table), repo.dialect.QuoteIdent(idCol))
+
+	if !preserveOrder {
+		return repo.MapRowsN(ctx, query, map[string]any{"ids": ids}, mapFunc)
+	}
+
+	// remember which id each mapped row came from so the results can be sorted
+	rowIds := make(map[*T]string)
+	results, err := repo.MapRowsN(ctx, query, map[string]any{"ids": ids}, func(r *RowMap) (*T, error) {
+		t, err := mapFunc(r)
+		if t != nil {
+			rowIds[t] = idKey(rowId(r, idCol))
+		}
+		return t, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	order := make(map[string]int, len(ids))
+	for i, id := range ids {
+		if _, exists := order[idKey(id)]; !exists {
+			order[idKey(id)] = i
+		}
+	}
+
+	slices.SortStableFunc(results, func(a, b *T) int {
+		return order[rowIds[a]] - order[rowIds[b]]
+	})
+
+	return results, nil
+}
+
+// rowId reads the id column of a row, which is keyed by its lowercased name
+// when the repository was created WithLowercaseColumns.
+func rowId(r *RowMap, idCol string) any {
+	if v, ok := r.Raw(idCol); ok {
+		return v
+	}
+	v, _ := r.Raw(strings.ToLower(idCol))
+	return v
+}
+
+// idKey normalises an id so the one read from a row matches the one given by
+// the caller, whatever the types. []byte ids are compared as text and every
+// integer type as its decimal value.
+func idKey(id any) string {
+	if b, ok := id.([]byte); ok {
+		return string(b)
+	}
+
+	rv := reflect.ValueOf(id)
+	switch {
+	case rv.CanInt():
+		return strconv.FormatInt(rv.Int(), 10)
+	case rv.CanUint():
+		return strconv.FormatUint(rv.Uint(), 10)
+	}
+	return fmt.Sprint(id)
+}
+
+// FindByCompositeKeys loads the rows of table whose keyCols match one of keys,
+// for tables with a multi column primary key. Each key holds a value for every
+// column of keyCols, in the same order, and they are matched with a tuple IN
+// clause such as "(ArtistId, AlbumId) in ( ($1,$2), ($3,$4) )". The rows are
+// returned in whatever order the database returns them.
+func (repo repository[T]) FindByCompositeKeys(
+	ctx context.Context,
+	table string,
+	keyCols []string,
+	keys [][]any,
+	mapFunc MapFunc[T]) ([]*T, error) {
+
+	if err := checkIdent(append([]string{table}, keyCols...)...); err != nil {
+		return nil, err
+	}
+
+	if len(keyCols) == 0 {
+		return nil, errors.New("func FindByCompositeKeys() requires at least one key column")
+	}
+
+	for i, key := range keys {
+		if len(key) != len(keyCols) {
+			return nil, fmt.Errorf("key %d has %d value(s) for %d key column(s)", i, len(key), len(keyCols))
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	quoted := make([]string, len(keyCols))
+	for i, col := range keyCols {
+		quoted[i] = repo.dialect.QuoteIdent(col)
+	}
+
+	query := fmt.Sprintf("select * from %s where (%s) in ( :keys )", repo.dialect.QuoteIdent(table), strings.Join(quoted, ", "))
+
+	return repo.MapRowsN(ctx, query, map[string]any{"keys": keys}, mapFunc)
+}
+
+// ChunkIN runs a query like MapRowsN whose named parameter param is a slice
+// too large for a single IN clause, executing it once for each batch of at most
+// size elements and concatenating the results in batch order. The batches are
+// separate queries, so an order by, limit or aggregate applies within a batch
+// rather than over the whole result, and rows which match elements of more
+// than one batch are returned once for each.
+func (repo repository[T]) ChunkIN(
+	ctx context.Context,
+	sql string,
+	args map[string]any,
+	param string,
+	size int,
+	mapFunc MapFunc[T]) ([]*T, error) {
+
+	if size < 1 {
+		return nil, fmt.Errorf("func ChunkIN() batch size %d must be at least 1", size)
+	}
+
+	rv, ok := expandable(lookupArg(args, ":"+param))
+	if !ok {
+		return nil, fmt.Errorf("func ChunkIN() parameter %s is not a slice", param)
+	}
+
+	if rv.Len() == 0 {
+		return nil, fmt.Errorf("parameter %s is an empty slice and cannot be expanded into an IN clause", param)
+	}
+
+	batch := maps.Clone(args)
+	delete(batch, ":"+param)
+
+	var results []*T
+	for start := 0; start < rv.Len(); start += size {
+		batch[param] = rv.Slice(start, min(start+size, rv.Len())).Interface()
+
+		r, err := repo.MapRowsN(ctx, sql, batch, mapFunc)
+		if err != nil {
+			return nil, fmt.Errorf("func ChunkIN() failed on the batch starting at element %d: %w", start, err)
+		}
+		results = append(results, r...)
+	}
+
+	return results, nil
+}
+
+// whereClause builds the conditions for where, joined by and, numbering the
+// placeholders of d from position. Slice values become IN clauses with a
+// placeholder for each element, an empty slice is an error, and nil values
+// become is null checks. Columns are quoted for d.
+func whereClause(d Dialect, where map[string]any, position int) (string, []any, error) {
+	var conditions []string
+	var args []any
+
+	for _, col := range slices.Sorted(maps.Keys(where)) {
+		if err := checkIdent(col); err != nil {
+			return "", nil, err
+		}
+
+		val := where[col]
+		quoted := d.QuoteIdent(col)
+		if val == nil {
+			conditions = append(conditions, fmt.Sprintf("%s is null", quoted))
+			continue
+		}
+
+		rv, ok := expandable(val)
+		if !ok {
+			conditions = append(conditions, fmt.Sprintf("%s = %s", quoted, d.Placeholder(position)))
+			args = append(args, val)
+			position++
+			continue
+		}
+
+		if rv.Len() == 0 {
+			return "", nil, fmt.Errorf("where value for %s is an empty slice and cannot be expanded into an IN clause", col)
+		}
+
+		placeholders := make([]string, rv.Len())
+		for i := range placeholders {
+			placeholders[i] = d.Placeholder(position)
+			args = append(args, rv.Index(i).Interface())
+			position++
+		}
+		conditions = append(conditions, fmt.Sprintf("%s in ( %s )", quoted, strings.Join(placeholders, ", ")))
+	}
+
+	return strings.Join(conditions, " and "), args, nil
+}
+
+// updateSQL builds the update statement and arguments for Update, quoting the
+// identifiers for d.
+func updateSQL(d Dialect, table string, set map[string]any, where map[string]any) (string, []any, error) {
+	if err := checkIdent(table); err != nil {
+		return "", nil, err
+	}
+
+	if len(set) == 0 {
+		return "", nil, errors.New("update requires at least one column to set")
+	}
+
+	var assignments []string
+	var args []any
+
+	for _, col := range slices.Sorted(maps.Keys(set)) {
+		if err := checkIdent(col); err != nil {
+			return "", nil, err
+		}
+		args = append(args, set[col])
+		assignments = append(assignments, fmt.Sprintf("%s = %s", d.QuoteIdent(col), d.Placeholder(len(args))))
+	}
+
+	query := fmt.Sprintf("update %s set %s", d.QuoteIdent(table), strings.Join(assignments, ", "))
+
+	if len(where) == 0 {
+		return query, args, nil
+	}
+
+	conditions, whereArgs, err := whereClause(d, where, len(args)+1)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return query + " where " + conditions, append(args, whereArgs...), nil
+}
+
+// Update sets the columns in set on the rows of table matching every entry in
+// where. Slice values in where match any of their elements. An empty where
+// would update every row, so it is refused unless the repository was created
+// WithAllowFullTableUpdate.
+func (repo repository[T]) Update(
+	ctx context.Context,
+	table string,
+	set map[string]any,
+	where map[string]any) (Result, error) {
+
+	if len(where) == 0 && !repo.options.allowFullTableUpdate {
+		return Result{}, fmt.Errorf("refusing to update every row of %s without a where, see WithAllowFullTableUpdate", table)
+	}
+
+	query, args, err := updateSQL(repo.dialect, table, set, where)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return repo.Execute(ctx, query, args)
+}
+
+// deleteSQL builds the delete statement and arguments for Delete, quoting the
+// identifiers for d.
+func deleteSQL(d Dialect, table string, where map[string]any) (string, []any, error) {
+	if err := checkIdent(table); err != nil {
+		return "", nil, err
+	}
+
+	query := fmt.Sprintf("delete from %s", d.QuoteIdent(table))
+
+	if len(where) == 0 {
+		return query, nil, nil
+	}
+
+	conditions, args, err := whereClause(d, where, 1)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return query + " where " + conditions, args, nil
+}
+
+// Delete removes the rows of table matching every entry in where. Slice values
+// in where match any of their elements. An empty where would delete every row,
+// so it is refused unless the repository was created WithAllowFullTableDelete.
+func (repo repository[T]) Delete(
+	ctx context.Context,
+	table string,
+	where map[string]any) (Result, error) {
+
+	if len(where) == 0 && !repo.options.allowFullTableDelete {
+		return Result{}, fmt.Errorf("refusing to delete every row of %s without a where, see WithAllowFullTableDelete", table)
+	}
+
+	query, args, err := deleteSQL(repo.dialect, table, where)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return repo.Execute(ctx, query, args)
+}
+
+// insertSQL builds a single insert statement for every row in rows, quoting
+// the identifiers for d.
+func insertSQL(d Dialect, table string, columns []string, rows [][]any) (string, []any, error) {
+	if err := checkIdent(append([]string{table}, columns...)...); err != nil {
+		return "", nil, err
+	}
+
+	if len(columns) == 0 || len(rows) == 0 {
+		return "", nil, errors.New("insert requires at least one column and one row")
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = d.QuoteIdent(col)
+	}
+
+	values := make([]string, len(rows))
+	args := make([]any, 0, len(rows)*len(columns))
+
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return "", nil, fmt.Errorf("insert row %d has %d value(s) for %d column(s)", i, len(row), len(columns))
+		}
+
+		placeholders := make([]string, len(row))
+		for j, v := range row {
+			args = append(args, v)
+			placeholders[j] = d.Placeholder(len(args))
+		}
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	return fmt.Sprintf("insert into %s (%s) values %s",
+		d.QuoteIdent(table), strings.Join(quoted, ", "), strings.Join(values, ", ")), args, nil
+}
+
+// Insert adds rows to the columns of table with a single multi row insert and
+// returns the ids generated for them, in the order of rows. Neither MySQL nor
+// SQLite report more than one id, so the rest are computed from LastInsertId
+// and RowsAffected: MySQL reports the first id of the statement and SQLite the
+// last. This assumes the ids are allocated consecutively, which holds for an
+// insert of a known number of rows provided none of them supply their own
+// value for the auto increment column. On MySQL the ids step by
+// @@auto_increment_increment, which is read in the same transaction. An insert
+// which affects a different number of rows than given is refused rather than
+// guessed at. Postgres has no LastInsertId, use a returning clause instead.
+func (repo repository[T]) Insert(ctx context.Context, table string, columns []string, rows [][]any) ([]int64, error) {
+	if !repo.dialect.SupportsLastInsertId() {
+		return nil, fmt.Errorf("func Insert() cannot return generated ids with %T, use a returning clause", repo.dialect)
+	}
+
+	query, args, err := insertSQL(repo.dialect, table, columns, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+
+	err = repo.WithTx(ctx, func(tx Repository[T]) error {
+		step := int64(1)
+		if _, ok := repo.dialect.(MySQLDialect); ok {
+			if err := tx.ScanValues(ctx, "select @@auto_increment_increment", nil, &step); err != nil {
+				return fmt.Errorf("func Insert() failed to read auto_increment_increment: %w", err)
+			}
+		}
+
+		result, err := tx.Execute(ctx, query, args)
+		if err != nil {
+			return err
+		}
+
+		ids, err = generatedIds(repo.dialect, result, len(rows), step)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// generatedIds computes the n ids of a multi row insert from its Result, the
+// first id for MySQL and the last for SQLite, stepping by step.
+func generatedIds(d Dialect, result Result, n int, step int64) ([]int64, error) {
+	if result.RowsAffected != int64(n) {
+		return nil, fmt.Errorf("insert of %d row(s) affected %d, the generated ids cannot be computed", n, result.RowsAffected)
+	}
+
+	first := result.LastInsertId
+	if _, ok := d.(MySQLDialect); !ok {
+		first -= int64(n-1) * step
+	}
+
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = first + int64(i)*step
+	}
+
+	return ids, nil
+}
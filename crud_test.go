@@ -0,0 +1,354 @@
+package grepo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var artistAlbumMapper MapFunc[Album] = func(r *RowMap) (*Album, error) {
+	return &Album{
+		ArtistID: r.Int32("ArtistId"),
+		Title:    r.String("Name"),
+	}, r.Err()
+}
+
+func TestFindByIDs(t *testing.T) {
+	results, err := albums.FindByIDs(context.Background(), "Artist", "ArtistId", []any{3, 1, 2}, artistAlbumMapper, false)
+
+	if err != nil {
+		t.Fatalf("failed to find artists %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Errorf("want 3 results got %d", len(results))
+	}
+}
+
+func TestFindByIDsPreserveOrder(t *testing.T) {
+	results, err := albums.FindByIDs(context.Background(), "Artist", "ArtistId", []any{3, 1, 2}, artistAlbumMapper, true)
+
+	if err != nil {
+		t.Fatalf("failed to find artists %v", err)
+	}
+
+	var got []int32
+	for _, r := range results {
+		got = append(got, r.ArtistID)
+	}
+
+	if len(got) != 3 || got[0] != 3 || got[1] != 1 || got[2] != 2 {
+		t.Errorf("want artists in order [3 1 2] got %v", got)
+	}
+}
+
+func TestFindByIDsPreserveOrderLowercase(t *testing.T) {
+	lower := NewRepository[Album](testDB, WithLowercaseColumns())
+	results, err := lower.FindByIDs(context.Background(), "Artist", "ArtistId", []any{3, 1, 2}, func(r *RowMap) (*Album, error) {
+		return &Album{ArtistID: r.Int32("artistid")}, r.Err()
+	}, true)
+
+	if err != nil {
+		t.Fatalf("failed to find artists %v", err)
+	}
+
+	var got []int32
+	for _, r := range results {
+		got = append(got, r.ArtistID)
+	}
+
+	if len(got) != 3 || got[0] != 3 || got[1] != 1 || got[2] != 2 {
+		t.Errorf("want artists in order [3 1 2] got %v", got)
+	}
+}
+
+func TestIdKey(t *testing.T) {
+	for _, a := range [][2]any{
+		{[]byte("A7"), "A7"},
+		{int64(7), 7},
+		{int32(7), uint8(7)},
+	} {
+		if idKey(a[0]) != idKey(a[1]) {
+			t.Errorf("want %v (%T) and %v (%T) to match got %s and %s", a[0], a[0], a[1], a[1], idKey(a[0]), idKey(a[1]))
+		}
+	}
+}
+
+func TestFindByCompositeKeys(t *testing.T) {
+	results, err := albums.FindByCompositeKeys(context.Background(), "Album", []string{"ArtistId", "AlbumId"},
+		[][]any{{1, 1}, {1, 4}, {2, 2}, {2, 1}}, albumMapper)
+	if err != nil {
+		t.Fatalf("failed to find albums %v", err)
+	}
+
+	found := map[int64]int32{}
+	for _, album := range results {
+		found[album.AlbumID] = album.ArtistID
+	}
+
+	// album 1 belongs to artist 1, so the pair (2, 1) matches nothing
+	if len(results) != 3 || found[1] != 1 || found[4] != 1 || found[2] != 2 {
+		t.Errorf("want albums 1, 4 and 2 got %v", found)
+	}
+
+	if _, err = albums.FindByCompositeKeys(context.Background(), "Album", []string{"ArtistId", "AlbumId"},
+		[][]any{{1}}, albumMapper); err == nil {
+		t.Errorf("want error for a key missing a value got nil")
+	}
+}
+
+func TestChunkIN(t *testing.T) {
+	ids := make([]int, 70000)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+
+	query := "select AlbumId, Title, ArtistId from Album where AlbumId in ( :ids )"
+
+	_, err := albums.MapRowsN(context.Background(), query, map[string]any{"ids": ids}, albumMapper)
+	if !errors.Is(err, ErrTooManyParams) {
+		t.Fatalf("want ErrTooManyParams got %v", err)
+	}
+
+	results, err := albums.ChunkIN(context.Background(), query, map[string]any{"ids": ids}, "ids", 30000, albumMapper)
+	if err != nil {
+		t.Fatalf("failed to query in batches %v", err)
+	}
+
+	if len(results) != 347 {
+		t.Errorf("want 347 albums got %d", len(results))
+	}
+
+	// the default is the SQLite limit of 32766, well short of the Postgres one
+	_, err = albums.MapRowsN(context.Background(), query, map[string]any{"ids": ids[:40000]}, albumMapper)
+	if !errors.Is(err, ErrTooManyParams) || !strings.Contains(err.Error(), "limit is 32766") {
+		t.Errorf("want ErrTooManyParams at the SQLite limit got %v", err)
+	}
+
+	if _, err = albums.MapRowsN(context.Background(), query, map[string]any{"ids": ids[:32766]}, albumMapper); err != nil {
+		t.Errorf("want 32766 ids within the SQLite limit got %v", err)
+	}
+
+	capped := NewRepository[Album](testDB, WithMaxParams(2))
+	if _, err = capped.MapRows(context.Background(), "select AlbumId from Album where AlbumId in ( $1 )", []any{[]int{1, 2, 3}}, albumIdMapper); !errors.Is(err, ErrTooManyParams) {
+		t.Errorf("want ErrTooManyParams WithMaxParams got %v", err)
+	}
+}
+
+func TestFindByIDsInvalidIdent(t *testing.T) {
+	_, err := albums.FindByIDs(context.Background(), "Artist; drop table Artist", "ArtistId", []any{1}, artistAlbumMapper, false)
+
+	if err == nil {
+		t.Errorf("want error for invalid table name got nil")
+	}
+}
+
+func TestUpdateSQL(t *testing.T) {
+	query, args, err := updateSQL(
+		SQLiteDialect{},
+		"Artist",
+		map[string]any{"Name": "Grepo"},
+		map[string]any{"ArtistId": []int{1, 2}, "Name": nil})
+
+	if err != nil {
+		t.Fatalf("failed to build update %v", err)
+	}
+
+	want := `update "Artist" set "Name" = ?1 where "ArtistId" in ( ?2, ?3 ) and "Name" is null`
+	if query != want {
+		t.Errorf("want `%s` got `%s`", want, query)
+	}
+
+	if len(args) != 3 {
+		t.Errorf("want 3 args got %d", len(args))
+	}
+}
+
+func TestQuotedIdentifiers(t *testing.T) {
+	table := []struct {
+		name    string
+		dialect Dialect
+		table   string
+		want    string
+	}{
+		{"postgres reserved word", PostgresDialect{}, "order", `delete from "order" where "id" = $1`},
+		{"postgres schema", PostgresDialect{}, "public.order", `delete from "public"."order" where "id" = $1`},
+		{"mysql reserved word", MySQLDialect{}, "order", "delete from `order` where `id` = ?"},
+	}
+
+	for _, a := range table {
+		t.Run(a.name, func(t *testing.T) {
+			got, _, err := deleteSQL(a.dialect, a.table, map[string]any{"id": 1})
+			if err != nil {
+				t.Fatalf("failed to build delete %v", err)
+			}
+			if got != a.want {
+				t.Errorf("want `%s` got `%s`", a.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateReservedWord(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := testDB.Exec(`create table "order" ("id" integer primary key, "group" text)`); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+	defer func() { _, _ = testDB.Exec(`drop table "order"`) }()
+
+	if _, err := testDB.Exec(`insert into "order" ("group") values ('grepo')`); err != nil {
+		t.Fatalf("failed to insert row %v", err)
+	}
+
+	r, err := albums.Update(ctx, "order", map[string]any{"group": "grepo-updated"}, map[string]any{"group": "grepo"})
+	if err != nil {
+		t.Fatalf("failed to update %v", err)
+	}
+
+	if r.RowsAffected != 1 {
+		t.Errorf("want 1 row affected got %d", r.RowsAffected)
+	}
+
+	r, err = albums.Delete(ctx, "main.order", map[string]any{"group": "grepo-updated"})
+	if err != nil {
+		t.Fatalf("failed to delete %v", err)
+	}
+
+	if r.RowsAffected != 1 {
+		t.Errorf("want 1 row affected got %d", r.RowsAffected)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	inserted, err := albums.Execute(context.Background(), `insert into Artist ("name") values ($1)`, []any{"grepo-update"})
+	if err != nil {
+		t.Fatalf("failed to insert row %v", err)
+	}
+
+	r, err := albums.Update(
+		context.Background(),
+		"Artist",
+		map[string]any{"Name": "grepo-updated"},
+		map[string]any{"ArtistId": inserted.LastInsertId})
+
+	if err != nil {
+		t.Fatalf("failed to update row %v", err)
+	}
+
+	if r.RowsAffected != 1 {
+		t.Errorf("want 1 row affected got %d", r.RowsAffected)
+	}
+
+	var name string
+	if err = testDB.QueryRow(`select Name from Artist where ArtistId = $1`, inserted.LastInsertId).Scan(&name); err != nil {
+		t.Fatalf("failed to read row %v", err)
+	}
+
+	if name != "grepo-updated" {
+		t.Errorf("want grepo-updated got %s", name)
+	}
+}
+
+func TestUpdateRequiresWhere(t *testing.T) {
+	_, err := albums.Update(context.Background(), "Artist", map[string]any{"Name": "oops"}, nil)
+
+	if err == nil {
+		t.Errorf("want error updating without a where got nil")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	var ids []int64
+	for range 3 {
+		inserted, err := albums.Execute(context.Background(), `insert into Artist ("name") values ($1)`, []any{"grepo-delete"})
+		if err != nil {
+			t.Fatalf("failed to insert row %v", err)
+		}
+		ids = append(ids, inserted.LastInsertId)
+	}
+
+	r, err := albums.Delete(context.Background(), "Artist", map[string]any{"ArtistId": ids[0]})
+	if err != nil {
+		t.Fatalf("failed to delete row %v", err)
+	}
+
+	if r.RowsAffected != 1 {
+		t.Errorf("want 1 row affected got %d", r.RowsAffected)
+	}
+
+	r, err = albums.Delete(context.Background(), "Artist", map[string]any{"ArtistId": ids[1:]})
+	if err != nil {
+		t.Fatalf("failed to delete rows %v", err)
+	}
+
+	if r.RowsAffected != 2 {
+		t.Errorf("want 2 rows affected got %d", r.RowsAffected)
+	}
+}
+
+func TestDeleteRequiresWhere(t *testing.T) {
+	_, err := albums.Delete(context.Background(), "Artist", map[string]any{})
+
+	if err == nil {
+		t.Errorf("want error deleting without a where got nil")
+	}
+}
+
+func TestInsert(t *testing.T) {
+	names := []string{"grepo-insert-1", "grepo-insert-2", "grepo-insert-3"}
+
+	ids, err := albums.Insert(context.Background(), "Artist", []string{"Name"}, [][]any{{names[0]}, {names[1]}, {names[2]}})
+	if err != nil {
+		t.Fatalf("failed to insert rows %v", err)
+	}
+
+	if len(ids) != 3 || ids[1] != ids[0]+1 || ids[2] != ids[0]+2 {
+		t.Fatalf("want three sequential ids got %v", ids)
+	}
+
+	for i, id := range ids {
+		var name string
+		if err = testDB.QueryRow(`select Name from Artist where ArtistId = $1`, id).Scan(&name); err != nil {
+			t.Fatalf("failed to read row %v", err)
+		}
+		if name != names[i] {
+			t.Errorf("want %s for id %d got %s", names[i], id, name)
+		}
+	}
+}
+
+func TestInsertSQL(t *testing.T) {
+	query, args, err := insertSQL(MySQLDialect{}, "Artist", []string{"Name"}, [][]any{{"a"}, {"b"}})
+	if err != nil {
+		t.Fatalf("failed to build insert %v", err)
+	}
+
+	if want := "insert into `Artist` (`Name`) values (?), (?)"; query != want || len(args) != 2 {
+		t.Errorf("want `%s` with 2 args got `%s` with %v", want, query, args)
+	}
+
+	if _, _, err = insertSQL(MySQLDialect{}, "Artist", []string{"Name"}, [][]any{{"a", "b"}}); err == nil {
+		t.Errorf("want error for a row with too many values got nil")
+	}
+}
+
+func TestGeneratedIds(t *testing.T) {
+	// MySQL reports the first id, stepping by auto_increment_increment
+	ids, err := generatedIds(MySQLDialect{}, Result{RowsAffected: 3, LastInsertId: 10}, 3, 2)
+	if err != nil || ids[0] != 10 || ids[1] != 12 || ids[2] != 14 {
+		t.Errorf("want [10 12 14] got %v and %v", ids, err)
+	}
+
+	// SQLite reports the last
+	ids, err = generatedIds(SQLiteDialect{}, Result{RowsAffected: 3, LastInsertId: 10}, 3, 1)
+	if err != nil || ids[0] != 8 || ids[2] != 10 {
+		t.Errorf("want [8 9 10] got %v and %v", ids, err)
+	}
+
+	// an insert ignore which skipped a row would leave a gap
+	if _, err = generatedIds(MySQLDialect{}, Result{RowsAffected: 2, LastInsertId: 10}, 3, 1); err == nil {
+		t.Errorf("want error when fewer rows were affected got nil")
+	}
+}
@@ -0,0 +1,192 @@
+package grepo
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect describes the SQL differences between databases which matter to the
+// SQL grepo generates, such as the placeholders of named parameters and the
+// statements of Update and Delete.
+type Dialect interface {
+	// Placeholder returns the placeholder for the nth arg, counting from 1.
+	Placeholder(n int) string
+
+	// QuoteIdent quotes an identifier, or each part of a schema qualified one
+	// such as public.artist, so reserved words like order can be used as table
+	// and column names.
+	QuoteIdent(s string) string
+
+	// SupportsReturning reports whether insert, update and delete statements
+	// accept a returning clause.
+	SupportsReturning() bool
+
+	// SupportsLastInsertId reports whether the driver returns the id generated
+	// by an insert from sql.Result.LastInsertId.
+	SupportsLastInsertId() bool
+}
+
+// PostgresDialect numbers placeholders $1, $2 and quotes identifiers with
+// double quotes. Quoted identifiers are case-sensitive in Postgres, the name
+// must match the case the table or column was created with.
+type PostgresDialect struct{}
+
+func (PostgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (PostgresDialect) QuoteIdent(s string) string {
+	return quoteParts(s, `"`)
+}
+
+func (PostgresDialect) SupportsReturning() bool {
+	return true
+}
+
+// SupportsLastInsertId is false, lib/pq always errors, the id must be read
+// with a returning clause instead.
+func (PostgresDialect) SupportsLastInsertId() bool {
+	return false
+}
+
+// SQLiteDialect numbers placeholders ?1, ?2, which bind by number rather than
+// by order of appearance, and quotes identifiers with double quotes.
+type SQLiteDialect struct{}
+
+func (SQLiteDialect) Placeholder(n int) string {
+	return "?" + strconv.Itoa(n)
+}
+
+func (SQLiteDialect) QuoteIdent(s string) string {
+	return quoteParts(s, `"`)
+}
+
+// SupportsReturning is true, returning was added in SQLite 3.35.
+func (SQLiteDialect) SupportsReturning() bool {
+	return true
+}
+
+func (SQLiteDialect) SupportsLastInsertId() bool {
+	return true
+}
+
+// MySQLDialect uses unnumbered ? placeholders, which bind in order of
+// appearance, and quotes identifiers with backticks.
+type MySQLDialect struct{}
+
+func (MySQLDialect) Placeholder(_ int) string {
+	return "?"
+}
+
+func (MySQLDialect) QuoteIdent(s string) string {
+	return quoteParts(s, "`")
+}
+
+func (MySQLDialect) SupportsReturning() bool {
+	return false
+}
+
+func (MySQLDialect) SupportsLastInsertId() bool {
+	return true
+}
+
+// quoteParts quotes each dot separated part of name with q, doubling any q
+// within it.
+func quoteParts(name string, q string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = q + strings.ReplaceAll(part, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
+
+// dialectOf detects the dialect from the type of the database's driver,
+// falling back to PostgresDialect, whose $n placeholders are what grepo always
+// generated before dialects existed.
+func dialectOf(db *sql.DB) Dialect {
+	if db == nil {
+		return PostgresDialect{}
+	}
+
+	switch db.Driver().(type) {
+	case *pq.Driver:
+		return PostgresDialect{}
+	case *sqlite3.SQLiteDriver:
+		return SQLiteDialect{}
+	}
+
+	// MySQL is detected by name so the driver is not a dependency
+	if reflect.TypeOf(db.Driver()).String() == "*mysql.MySQLDriver" {
+		return MySQLDialect{}
+	}
+
+	return PostgresDialect{}
+}
+
+// maxParamsOf returns the limit d places on the parameters of a statement,
+// 32766 for SQLite since 3.32.0 and 65535 for Postgres and MySQL, which is
+// also assumed for any other dialect.
+func maxParamsOf(d Dialect) int {
+	if _, ok := d.(SQLiteDialect); ok {
+		return 32766
+	}
+	return 65535
+}
+
+// sqliteTimeLayout is the layout SQLite's date and time functions, and the
+// sqlite3 driver when reading DATETIME columns, understand.
+const sqliteTimeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+// timeLayouts are tried in order when a time arrives as text.
+var timeLayouts = []string{
+	sqliteTimeLayout,
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	time.DateOnly,
+}
+
+// bindArgs prepares args for the repository's dialect before they are bound.
+// SQLite has no time type, so time.Time args are formatted as text in a layout
+// its date functions can compare and parse, Postgres takes them as they are.
+func (repo repository[T]) bindArgs(args []any) []any {
+	if _, ok := repo.dialect.(SQLiteDialect); !ok {
+		return args
+	}
+
+	var bound []any
+	for i, arg := range args {
+		var formatted string
+		switch v := arg.(type) {
+		case time.Time:
+			formatted = v.Format(sqliteTimeLayout)
+		case *time.Time:
+			if v == nil {
+				continue
+			}
+			formatted = v.Format(sqliteTimeLayout)
+		default:
+			continue
+		}
+
+		// copy on the first time so the caller's slice is left alone
+		if bound == nil {
+			bound = make([]any, len(args))
+			copy(bound, args)
+		}
+		bound[i] = formatted
+	}
+
+	if bound == nil {
+		return args
+	}
+	return bound
+}
@@ -0,0 +1,116 @@
+package grepo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDialectPlaceholder(t *testing.T) {
+	table := []struct {
+		dialect Dialect
+		want    []string
+	}{
+		{PostgresDialect{}, []string{"$1", "$2", "$10"}},
+		{SQLiteDialect{}, []string{"?1", "?2", "?10"}},
+		{MySQLDialect{}, []string{"?", "?", "?"}},
+	}
+
+	for _, a := range table {
+		for i, n := range []int{1, 2, 10} {
+			if got := a.dialect.Placeholder(n); got != a.want[i] {
+				t.Errorf("want %s from %T got %s", a.want[i], a.dialect, got)
+			}
+		}
+	}
+
+	if (MySQLDialect{}).SupportsReturning() || !(PostgresDialect{}).SupportsReturning() {
+		t.Errorf("want returning supported by postgres and not mysql")
+	}
+
+	if (PostgresDialect{}).SupportsLastInsertId() || !(SQLiteDialect{}).SupportsLastInsertId() {
+		t.Errorf("want LastInsertId supported by sqlite and not postgres")
+	}
+
+	if maxParamsOf(SQLiteDialect{}) != 32766 || maxParamsOf(PostgresDialect{}) != 65535 {
+		t.Errorf("want the parameter limits of sqlite and postgres")
+	}
+}
+
+func TestDialectNamedParameters(t *testing.T) {
+	query, args, err := bindNamedWith(MySQLDialect{}, "select * from Album where AlbumId in ( :ids ) and Title = :title",
+		map[string]any{"ids": []int{1, 2}, "title": "x"}, 1)
+
+	if err != nil {
+		t.Fatalf("failed to bind %v", err)
+	}
+
+	if want := "select * from Album where AlbumId in ( ?, ? ) and Title = ?"; query != want || len(args) != 3 {
+		t.Errorf("want `%s` with 3 args got `%s` with %v", want, query, args)
+	}
+
+	// a name used twice is bound twice, the placeholders are not numbered
+	query, args, err = bindNamedWith(MySQLDialect{}, "select * from Album where ArtistId in ( :ids ) or AlbumId in ( :ids ) and Title <> :title",
+		map[string]any{"ids": []int{1, 2}, "title": "x"}, 1)
+
+	if err != nil {
+		t.Fatalf("failed to bind %v", err)
+	}
+
+	if want := "select * from Album where ArtistId in ( ?, ? ) or AlbumId in ( ?, ? ) and Title <> ?"; query != want ||
+		!reflect.DeepEqual(args, []any{1, 2, 1, 2, "x"}) {
+		t.Errorf("want `%s` with [1 2 1 2 x] got `%s` with %v", want, query, args)
+	}
+
+	if err = (repository[Album]{dialect: MySQLDialect{}}).checkArgs(query, args); err != nil {
+		t.Errorf("want the args to match the placeholders got %v", err)
+	}
+
+	fragment, _, err := BindNamedDialect(SQLiteDialect{}, "Title = :title", map[string]any{"title": "x"}, 3)
+	if err != nil || fragment != "Title = ?3" {
+		t.Errorf("want `Title = ?3` got `%s` and %v", fragment, err)
+	}
+
+	// the sqlite repository binds ?n placeholders
+	results, err := albums.MapRowsN(context.Background(), "select AlbumId, Title, ArtistId from Album where ArtistId = :artist and AlbumId in ( :ids )",
+		map[string]any{"ids": []int{1, 2, 4}, "artist": 1}, albumMapper)
+
+	if err != nil || len(results) != 2 {
+		t.Errorf("want 2 albums got %d and %v", len(results), err)
+	}
+
+	results, err = albums.MapRowsN(context.Background(), "select AlbumId, Title, ArtistId from Album where ArtistId = :artist or AlbumId = :artist",
+		map[string]any{"artist": 2}, albumMapper)
+
+	if err != nil || len(results) != 2 {
+		t.Errorf("want albums 2 and 3 for a repeated :artist got %d and %v", len(results), err)
+	}
+}
+
+func TestBindArgs(t *testing.T) {
+	at := time.Date(2024, time.March, 9, 14, 30, 0, 0, time.UTC)
+	args := []any{at, 1}
+
+	postgres := repository[Album]{dialect: PostgresDialect{}}
+	if got := postgres.bindArgs(args); got[0] != at {
+		t.Errorf("want the time untouched for postgres got %v", got[0])
+	}
+
+	sqlite := repository[Album]{dialect: SQLiteDialect{}}
+	if got := sqlite.bindArgs(args); got[0] != "2024-03-09 14:30:00+00:00" || got[1] != 1 {
+		t.Errorf("want the time formatted for sqlite got %v", got)
+	}
+
+	if args[0] != at {
+		t.Errorf("want the caller's args left alone got %v", args[0])
+	}
+
+	if got := dialectOf(testDB); got != (SQLiteDialect{}) {
+		t.Errorf("want the sqlite dialect detected got %T", got)
+	}
+
+	if got := NewRepository[Album](testDB, WithDialect(MySQLDialect{})).(*repository[Album]).dialect; got != (MySQLDialect{}) {
+		t.Errorf("want the dialect set WithDialect got %T", got)
+	}
+}
@@ -0,0 +1,85 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// fakeDriver is a minimal driver used to exercise behaviour the SQLite driver
+// does not implement, such as enforcing read-only transactions.
+type fakeDriver struct{}
+
+func init() {
+	sql.Register("grepo-fake", fakeDriver{})
+}
+
+// fakeOutage takes down the server of every connection string containing
+// flaky, new connections are refused and existing ones are broken.
+var fakeOutage atomic.Bool
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	// like some real drivers, the error repeats the connection string
+	if strings.Contains(dsn, "unreachable") || (fakeOutage.Load() && strings.Contains(dsn, "flaky")) {
+		return nil, fmt.Errorf("fake: cannot connect with %s", dsn)
+	}
+	return &fakeConn{flaky: strings.Contains(dsn, "flaky")}, nil
+}
+
+type fakeConn struct {
+	txOptions *driver.TxOptions
+	flaky     bool
+}
+
+func (c *fakeConn) Ping(_ context.Context) error {
+	if c.flaky && fakeOutage.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *fakeConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("fake: prepare is not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.txOptions = &opts
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.txOptions = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.txOptions = nil
+	return nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	if c.flaky && fakeOutage.Load() {
+		return nil, driver.ErrBadConn
+	}
+	if c.txOptions != nil && c.txOptions.ReadOnly {
+		return nil, errors.New("fake: cannot execute a write in a read-only transaction")
+	}
+	// like Postgres, the fake does not support LastInsertId
+	return driver.RowsAffected(1), nil
+}
+
+func openFake() (*sql.DB, error) {
+	return sql.Open("grepo-fake", "")
+}
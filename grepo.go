@@ -2,26 +2,43 @@
 package grepo
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"maps"
+	"math/big"
 	"reflect"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
-type Connector interface {
-	GetConnection() (*sql.DB, error)
-}
-
 // MapFunc is a generic function type that converts a map of string-any pairs into a specific type T.
 type MapFunc[T any] func(r *RowMap) (*T, error)
 type ApplyFunc[T any] func(t *T, r *RowMap) (*T, error)
 
+// Simple adapts a mapper which does not return an error into a MapFunc. Any
+// errors accumulated on the RowMap while mapping are returned automatically.
+func Simple[T any](fn func(r *RowMap) *T) MapFunc[T] {
+	return func(r *RowMap) (*T, error) {
+		t := fn(r)
+		if err := r.Err(); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+}
+
 // Repository defines a generic interface for database operations on type T.
 type Repository[T any] interface {
 	// MapRow executes a query and maps a single row into type T using the provided map function.
@@ -31,25 +48,207 @@ type Repository[T any] interface {
 	MapRowN(ctx context.Context, sql string, args map[string]any, mapFunc MapFunc[T]) (*T, error)
 
 	// MapRows executes a query and maps multiple rows into type T using the provided map function.
+	// WithContinueOnError it maps every row, returning the mapped rows along with the mapper errors.
 	MapRows(ctx context.Context, sql string, args []any, mapFunc MapFunc[T]) ([]*T, error)
 
+	// MapRowsApply executes a query, maps each row with initFunc and then passes it through applyFuncs in order.
+	MapRowsApply(ctx context.Context, sql string, args []any, initFunc MapFunc[T], applyFuncs ...ApplyFunc[T]) ([]*T, error)
+
+	// MapRowsValues executes a query and collects the mapped rows by value rather than by pointer.
+	MapRowsValues(ctx context.Context, sql string, args []any, mapFunc MapFunc[T]) ([]T, error)
+
+	// MapRowsInto executes a query and appends the mapped rows to dest after resetting its length.
+	MapRowsInto(ctx context.Context, sql string, args []any, mapFunc MapFunc[T], dest *[]*T) error
+
+	// MapRowsChan executes a query and streams the mapped rows over a channel as they are read.
+	MapRowsChan(ctx context.Context, sql string, args []any, mapFunc MapFunc[T]) (<-chan *T, <-chan error)
+
+	// MapRowsPooled executes a query filling instances acquired from a pool rather than allocating each T.
+	MapRowsPooled(ctx context.Context, sql string, args []any, acquire func() *T, reset func(*T), fill FillFunc[T]) ([]*T, error)
+
+	// MapRowsCached executes a query like MapRows, serving repeated identical queries from a cache for ttl.
+	MapRowsCached(ctx context.Context, ttl time.Duration, sql string, args []any, mapFunc MapFunc[T]) ([]*T, error)
+
+	// MapRowsParallel executes independent queries concurrently using at most workers connections.
+	MapRowsParallel(ctx context.Context, workers int, batches []Batch[T]) ([][]*T, error)
+
+	// Prepare binds a query to a mapper so it can be defined once and executed many times.
+	Prepare(sql string, mapFunc MapFunc[T]) PreparedQuery[T]
+
+	// Query executes a query and returns the row maps without mapping them into T.
+	Query(ctx context.Context, sql string, args []any) ([]*RowMap, error)
+
+	// QueryN executes a query with named parameters and returns the row maps without mapping them into T.
+	QueryN(ctx context.Context, sql string, args map[string]any) ([]*RowMap, error)
+
+	// Columns executes a query and describes its result columns without mapping any rows.
+	Columns(ctx context.Context, sql string, args []any) ([]ColumnInfo, error)
+
+	// ScanValues executes a query and scans its single row into the pointers in dest.
+	ScanValues(ctx context.Context, sql string, args []any, dest ...any) error
+
+	// Strings executes a single column query and returns its values as strings.
+	Strings(ctx context.Context, sql string, args []any) ([]string, error)
+
+	// Aggregate executes a single row query and scans its columns into the fields of T in order.
+	Aggregate(ctx context.Context, sql string, args []any) (*T, error)
+
+	// MapRowsNStruct executes a query taking its named parameters from the fields of entity.
+	MapRowsNStruct(ctx context.Context, sql string, entity any, mapFunc MapFunc[T]) ([]*T, error)
+
 	// MapRowsN executes a query and maps multiple rows into type T using the provided map function.
 	MapRowsN(ctx context.Context, sql string, args map[string]any, mapFunc MapFunc[T]) ([]*T, error)
 
 	// Execute experimental update, does not support slices yet.
 	Execute(ctx context.Context, sql string, args []any) (Result, error)
+
+	// ExecuteN performs the given query with named parameters, expanding slices as MapRowsN does.
+	ExecuteN(ctx context.Context, sql string, args map[string]any) (Result, error)
+
+	// ExecuteNoTx performs the given query with args without wrapping it in a transaction.
+	ExecuteNoTx(ctx context.Context, sql string, args []any) (Result, error)
+
+	// ExecuteScript runs each statement in a single transaction and returns a Result per statement.
+	ExecuteScript(ctx context.Context, statements []string) ([]Result, error)
+
+	// CopyFrom bulk loads rows into the columns of a Postgres table using COPY.
+	CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error)
+
+	// Insert adds rows to table with a single statement and returns their generated ids.
+	Insert(ctx context.Context, table string, columns []string, rows [][]any) ([]int64, error)
+
+	// Update sets the columns in set on the rows of table matching where.
+	Update(ctx context.Context, table string, set map[string]any, where map[string]any) (Result, error)
+
+	// Delete removes the rows of table matching where.
+	Delete(ctx context.Context, table string, where map[string]any) (Result, error)
+
+	// ChunkIN executes a named query once per batch of a large slice parameter and concatenates the results.
+	ChunkIN(ctx context.Context, sql string, args map[string]any, param string, size int, mapFunc MapFunc[T]) ([]*T, error)
+
+	// FindByCompositeKeys maps the rows of table whose keyCols match one of keys.
+	FindByCompositeKeys(ctx context.Context, table string, keyCols []string, keys [][]any, mapFunc MapFunc[T]) ([]*T, error)
+
+	// Ident validates a table or column name, optionally against allowed, and quotes it for the dialect.
+	Ident(name string, allowed ...string) (string, error)
+
+	// FindByIDs maps the rows of table whose idCol matches one of ids.
+	FindByIDs(ctx context.Context, table string, idCol string, ids []any, mapFunc MapFunc[T], preserveOrder bool) ([]*T, error)
+
+	// Stats returns the connection pool statistics of the underlying database.
+	Stats() sql.DBStats
+
+	// WithTx runs fn against a repository bound to a single transaction.
+	WithTx(ctx context.Context, fn func(tx Repository[T]) error) error
+
+	// Savepoint marks a point within the transaction that RollbackTo can return to.
+	Savepoint(ctx context.Context, name string) error
+
+	// RollbackTo undoes the work done within the transaction since the savepoint.
+	RollbackTo(ctx context.Context, name string) error
+
+	// ReleaseSavepoint discards the savepoint, keeping the work done since it.
+	ReleaseSavepoint(ctx context.Context, name string) error
 }
 
-func NewRepository[T any](db *sql.DB) Repository[T] {
+func NewRepository[T any](db *sql.DB, opts ...Option) Repository[T] {
+	o := newOptions(opts)
+
+	d := o.dialect
+	if d == nil {
+		d = dialectOf(db)
+	}
+
 	return &repository[T]{
 		database: db,
+		options:  o,
+		cache:    newResultCache[T](),
+		dialect:  d,
+	}
+}
+
+// NewRepositoryFromConnector returns a repository bound to the database of c,
+// so the connector's retry and pooling behaviour applies, such as the retries
+// of the PostgresConnector. The connector still owns the database, close it
+// through the connector.
+func NewRepositoryFromConnector[T any](c Connector, opts ...Option) (Repository[T], error) {
+	db, err := c.GetConnection()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get a connection from the connector: %w", err)
+	}
+
+	return NewRepository[T](db, opts...), nil
+}
+
+// ErrTooManyRows is returned when a query produces more rows than the limit set
+// WithMaxRows, or more than the one row expected by MapRow.
+var ErrTooManyRows = errors.New("query returned too many rows")
+
+// ErrEmptyQuery is returned when the SQL to be executed is empty or only
+// whitespace, usually a query builder which produced nothing, rather than
+// passing it on for the driver to reject in its own words.
+var ErrEmptyQuery = errors.New("query is empty")
+
+// checkQuery returns ErrEmptyQuery for a query with nothing to execute.
+func checkQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return ErrEmptyQuery
 	}
+	return nil
 }
 
 // repository is the concrete implementation of Repository interface.
 type repository[T any] struct {
 	// database holds the database connection
 	database *sql.DB
+	// tx is set when the repository is bound to a transaction by WithTx
+	tx      *sql.Tx
+	options options
+	// cache holds the results of MapRowsCached
+	cache *resultCache[T]
+	// dialect is detected from the database's driver unless set WithDialect
+	dialect Dialect
+}
+
+// conn returns the transaction the repository is bound to, if any, otherwise
+// the database itself.
+func (repo repository[T]) conn() queryer {
+	if repo.tx != nil {
+		return repo.tx
+	}
+	return repo.database
+}
+
+// Stats returns the connection pool statistics of the underlying database, such
+// as the open and idle connections and how often callers waited for one, for
+// monitoring. A repository bound to a transaction reports its database's pool,
+// one without a database, such as the zero repository, reports zero stats.
+func (repo repository[T]) Stats() sql.DBStats {
+	if repo.database == nil {
+		return sql.DBStats{}
+	}
+	return repo.database.Stats()
+}
+
+// withTimeout applies the repository's default query timeout to ctx, unless no
+// timeout is configured or ctx already carries a deadline of its own.
+func (repo repository[T]) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if repo.options.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, repo.options.queryTimeout)
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
 func (repo repository[T]) MapRow(
@@ -58,16 +257,28 @@ func (repo repository[T]) MapRow(
 	args []any,
 	mapFunc MapFunc[T]) (*T, error) {
 
-	// Yes, this function cheats and uses MapRows, which returns
-	// a slice rather than a single value of T,
-	// because sql.QueryRow does not allow for the ability
-	// to retrieve the column names. It's not a flaw here, it's
-	// a performance issue this module does not care to solve,
-	// in this specific context. If you want performance without
-	// the overhead of retrieving column names and creating maps,
-	// then use the ScanRow/ScanRows function which are less
-	// flexible.
-	result, err := repo.MapRows(ctx, sql, args, mapFunc)
+	// sql.QueryRow does not allow for the ability to retrieve the column
+	// names, so the rows are read the same way as MapRows, but the query is
+	// abandoned as soon as a second row arrives rather than reading and mapping
+	// every row just to count them.
+	var result []*T
+
+	err := repo.eachRow(ctx, sql, args, func(rowMap *RowMap) error {
+		if len(result) == 1 {
+			return fmt.Errorf("MapRow expected 0 or 1 rows: %w", ErrTooManyRows)
+		}
+		r, err := repo.mapRow(mapFunc, rowMap)
+		if err != nil {
+			return err
+		}
+		result = append(result, r)
+		return nil
+	})
+
+	if errors.Is(err, ErrTooManyRows) {
+		slog.Error("MapRow resulted in more than one row when expecting was 0 or 1")
+		return nil, err
+	}
 
 	if err != nil {
 		// would actually log this not just return an error
@@ -75,11 +286,6 @@ func (repo repository[T]) MapRow(
 		return nil, errors.Join(errors.New("error occurred while executing row mapper"), err)
 	}
 
-	if len(result) > 1 {
-		slog.Error("MapRow resulted in %d rows when expecting was 0 or 1", "grepo", len(result))
-		return nil, fmt.Errorf("MapRow resulted in %d rows when expecting was 0 or 1", len(result))
-	}
-
 	slog.Debug("MapRow resulted in %d row(s)", "grepo", len(result))
 	if len(result) == 0 {
 		return nil, nil
@@ -94,19 +300,16 @@ func (repo repository[T]) MapRowN(
 	args map[string]any,
 	mapFunc MapFunc[T]) (*T, error) {
 
-	entries := namedParameters(sql, args)
-	query, err := substitute(sql, entries)
+	query, newArgs, err := repo.bind(sql, args)
 
 	if err != nil {
-		return nil, fmt.Errorf("substitution of named parameters failed %w", err)
+		return nil, err
 	}
 
-	newArgs := flattenArgs(entries)
-
 	result, err := repo.MapRow(ctx, query, newArgs, mapFunc)
 
 	if err != nil {
-		slog.Error(fmt.Sprintf("unable to execute query '%s' with parameters %v", query, args))
+		slog.Error(fmt.Sprintf("unable to execute query '%s' with parameters %v", query, redactNamed(args, repo.options.unredactedArgs)))
 		return nil, err
 	}
 
@@ -114,320 +317,1685 @@ func (repo repository[T]) MapRowN(
 }
 
 func (repo repository[T]) MapRows(
-	_ context.Context,
+	ctx context.Context,
 	sql string,
 	args []any,
 	mapFunc MapFunc[T],
 ) ([]*T, error) {
-	stmt, err := repo.database.Prepare(sql)
-	if err != nil {
-		slog.Error("error preparing statement", "err", err.Error())
-		return nil, err
-	}
-
-	defer func() {
-		if err = stmt.Close(); err != nil {
-			slog.Error("error closing statement %w", "err", err.Error())
-		}
-	}()
+	var results []*T
+	var rowErrs []error
+	row := 0
 
-	// Expand all arguments to their positions in the statement.
-	// Is reflection the correct thing? Type assertions were ugly, but perhaps a better way? not sure.
-	for i, arg := range args {
-		switch v := arg.(type) {
-		default:
-			// Check if it's any kind of slice
-			rv := reflect.ValueOf(v)
-			if rv.Kind() == reflect.Slice {
-				replacements := make([]string, rv.Len())
-				if rv.IsValid() && !rv.IsNil() {
-					for i := 0; i < rv.Len(); i++ {
-						elem := rv.Index(i)
-						switch elem.Kind() {
-						case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-							replacements[i] = fmt.Sprintf("%d", elem.Int())
-						case reflect.Bool:
-							replacements[i] = fmt.Sprintf("%t", elem.Bool())
-						case reflect.String:
-							replacements[i] = fmt.Sprintf("'%s'", elem.String())
-						case reflect.Float32, reflect.Float64:
-							replacements[i] = fmt.Sprintf("%f", elem.Float())
-						default:
-							replacements[i] = fmt.Sprintf("%v", elem.Interface())
-						}
-					}
-				}
-				args[i] = strings.Join(replacements, ", ")
+	err := repo.eachRow(ctx, sql, args, func(rowMap *RowMap) error {
+		row++
+		r, err := repo.mapRow(mapFunc, rowMap)
+		if err != nil {
+			if repo.options.continueOnError {
+				rowErrs = append(rowErrs, fmt.Errorf("row %d: %w", row, err))
+				return nil
 			}
+			return err
 		}
-	}
-	rows, err := stmt.Query(args...)
+		results = append(results, r)
+		return nil
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		err := rows.Close()
-		if err != nil {
-			slog.Error("error closing rows %w", "err", err)
-		}
-	}()
-
-	cols, err := rows.Columns()
+	slog.Debug("MapRows resulted in %d row(s)", "grepo", len(results))
 
-	if err != nil {
-		return nil, err
+	if len(rowErrs) > 0 {
+		return results, errors.Join(rowErrs...)
 	}
 
+	return results, nil
+}
+
+// MapRowsApply maps each row with initFunc and then runs the result through
+// applyFuncs in order, each receiving the value returned by the one before it
+// and the same RowMap, for enrichment or validation which is shared between
+// mappers. The first error from any of them stops the query.
+func (repo repository[T]) MapRowsApply(
+	ctx context.Context,
+	sql string,
+	args []any,
+	initFunc MapFunc[T],
+	applyFuncs ...ApplyFunc[T],
+) ([]*T, error) {
 	var results []*T
-	values := make([]any, len(cols))
-	ptrs := make([]any, len(values))
 
-	for rows.Next() {
-		// Take addresses directly in the Scan call
-		for i := range values {
-			ptrs[i] = &values[i]
+	err := repo.eachRow(ctx, sql, args, func(rowMap *RowMap) error {
+		r, err := repo.mapRow(initFunc, rowMap)
+		if err != nil {
+			return err
 		}
 
-		if err = rows.Scan(ptrs...); err != nil {
-			return nil, err
+		for _, apply := range applyFuncs {
+			if r, err = apply(r, rowMap); err != nil {
+				return err
+			}
 		}
 
-		rowMap := toMap(cols, values)
-
-		r, err := mapFunc(rowMap)
-		if err != nil {
-			return nil, err
-		}
 		results = append(results, r)
-	}
+		return nil
+	})
 
-	if err = rows.Err(); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	slog.Debug("MapRows resulted in %d row(s)", "grepo", len(results))
+	slog.Debug("MapRowsApply resulted in %d row(s)", "grepo", len(results))
 
 	return results, nil
 }
 
-func (repo repository[T]) MapRowsN(
+func (repo repository[T]) MapRowsValues(
 	ctx context.Context,
 	sql string,
-	args map[string]any,
-	mapFunc MapFunc[T]) ([]*T, error) {
+	args []any,
+	mapFunc MapFunc[T],
+) ([]T, error) {
+	var results []T
 
-	entries := namedParameters(sql, args)
-	query, err := substitute(sql, entries)
+	err := repo.eachRow(ctx, sql, args, func(rowMap *RowMap) error {
+		r, err := repo.mapRow(mapFunc, rowMap)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			return fmt.Errorf("MapRowsValues mapper returned a nil value")
+		}
+		results = append(results, *r)
+		return nil
+	})
 
 	if err != nil {
-		return nil, fmt.Errorf("substitution of named parameters failed %w", err)
+		return nil, err
 	}
 
-	newArgs := flattenArgs(entries)
-	result, err := repo.MapRows(ctx, query, newArgs, mapFunc)
+	slog.Debug("MapRowsValues resulted in %d row(s)", "grepo", len(results))
+
+	return results, nil
+}
+
+// MapRowsInto executes a query like MapRows but appends the results to dest,
+// resetting its length first, so a pre-sized slice can be reused across calls
+// without allocating a new one every time.
+func (repo repository[T]) MapRowsInto(
+	ctx context.Context,
+	sql string,
+	args []any,
+	mapFunc MapFunc[T],
+	dest *[]*T,
+) error {
+	*dest = (*dest)[:0]
+
+	err := repo.eachRow(ctx, sql, args, func(rowMap *RowMap) error {
+		r, err := repo.mapRow(mapFunc, rowMap)
+		if err != nil {
+			return err
+		}
+		*dest = append(*dest, r)
+		return nil
+	})
 
 	if err != nil {
-		slog.Error(fmt.Sprintf("unable to execute query '%s' with parameters %v", query, args))
-		return nil, err
+		*dest = (*dest)[:0]
+		return err
 	}
 
-	return result, nil
+	slog.Debug("MapRowsInto resulted in %d row(s)", "grepo", len(*dest))
+
+	return nil
 }
 
-func flattenArgs(entries map[string]paramEntry) []any {
-	var newArgs []any
-	// need the entries sorted by their position so they wind up in the correct place when
-	// executed
-	for _, pe := range slices.SortedFunc(maps.Values(entries), paramSortFunc) {
-		switch v := pe.val.(type) {
-		default:
-			// Check if it's any kind of slice
-			rv := reflect.ValueOf(v)
-			if rv.Kind() == reflect.Slice {
-				if rv.IsValid() && !rv.IsNil() {
-					for i := 0; i < rv.Len(); i++ {
-						elem := rv.Index(i)
-						if elem.IsValid() {
-							newArgs = append(newArgs, elem.Interface())
-						}
-					}
-					pe.len = rv.Len()
-				}
-			} else {
-				newArgs = append(newArgs, pe.val)
-			}
+// mapRow invokes mapFunc for a single row, applying the repository's checks on
+// how the mapper used the row.
+func (repo repository[T]) mapRow(mapFunc MapFunc[T], rowMap *RowMap) (*T, error) {
+	r, err := mapFunc(rowMap)
+
+	if repo.options.strictColumns && len(rowMap.missing) > 0 {
+		return nil, fmt.Errorf("mapper requested column(s) %v which are not in the result, available columns are %v",
+			rowMap.missing, rowMap.cols)
+	}
+
+	if err == nil && repo.options.failOnRowMapError {
+		if rerr := rowMap.Err(); rerr != nil {
+			return nil, fmt.Errorf("mapper ignored errors reading the row: %w", rerr)
 		}
 	}
 
-	return newArgs
+	return r, err
 }
 
-// Execute performs the given query with args and returns a Result
-func (repo repository[T]) Execute(
+// eachRow executes the query and calls fn with a RowMap for every row returned,
+// stopping at the first error.
+func (repo repository[T]) eachRow(
 	ctx context.Context,
-	sql string,
-	args []any) (Result, error) {
-
-	tx, err := repo.database.BeginTx(ctx, nil)
-
-	if err != nil {
-		slog.Error(fmt.Sprintf("unable to begin a transaction Execute() %v", err))
-		return Result{}, fmt.Errorf("function Execute() errored on Exec %w", err)
+	query string,
+	args []any,
+	fn func(rowMap *RowMap) error,
+) error {
+	return repo.queryRows(ctx, query, args, func(rows *sql.Rows) error {
+		return repo.scanRows(rows, fn)
+	})
+}
 
+// queryRows executes the query and hands the result to fn, which is
+// responsible for closing rows. The query is sent directly rather than
+// explicitly prepared and closed, which saves a round trip for drivers which
+// can bind args without a prepared statement, use Prepare for a query which
+// will be executed many times.
+func (repo repository[T]) queryRows(
+	ctx context.Context,
+	query string,
+	args []any,
+	fn func(rows *sql.Rows) error,
+) error {
+	if err := checkQuery(query); err != nil {
+		return err
 	}
 
-	result, err := tx.Exec(sql, args...)
+	ctx, cancel := repo.withTimeout(ctx)
+	defer cancel()
+
+	// Expand any slice arguments into their own placeholders, the same as the
+	// named parameter path does, rather than joining them into the statement.
+	query, args, err := expandPositional(repo.dialect, query, args)
 	if err != nil {
-		_ = tx.Rollback()
-		slog.Error(fmt.Sprintf("func Execute() errored on Exec %v", err))
-		return Result{}, fmt.Errorf("func Execute() errored on Exec: %w", err)
+		return err
 	}
+	if err = repo.checkArgs(query, args); err != nil {
+		return err
+	}
+	args = repo.bindArgs(args)
 
-	err = tx.Commit()
+	repo.logQuery(ctx, query, args)
+	rows, err := repo.conn().QueryContext(ctx, query, args...)
 
 	if err != nil {
-		slog.Error(fmt.Sprintf("error executing commit in Execute()  %v", err))
-		return Result{}, fmt.Errorf("func Execute() failed during Commit: %w", err)
+		slog.Error("error executing query", "err", err.Error())
+		return repo.queryError(query, args, err)
 	}
 
-	var lastInsertId int64
-	var rowsAffected int64
+	return fn(rows)
+}
 
-	rowsAffected, rerr := result.RowsAffected()
+// ScanValues executes a query expected to return exactly one row and scans its
+// columns directly into dest, in order, using rows.Scan. sql.ErrNoRows is
+// returned when there is no row.
+func (repo repository[T]) ScanValues(ctx context.Context, query string, args []any, dest ...any) error {
+	return repo.queryRows(ctx, query, args, func(rows *sql.Rows) error {
+		defer func() {
+			if err := rows.Close(); err != nil {
+				slog.Error("error closing rows %w", "err", err)
+			}
+		}()
 
-	// There is some wonky attempts at capturing some errors here, just in case
-	// one of the two result calls causes an error. We may not want to fail
-	// completely. TODO need error types.
-	if rerr != nil {
-		slog.Error(fmt.Sprintf("error extracting rows affected from result %v", err))
-		rowsAffected = -1
-	}
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
 
-	lastInsertId, err = result.LastInsertId()
+		if len(cols) != len(dest) {
+			return NewScanCountError(cols, len(dest))
+		}
 
-	if err != nil {
-		slog.Error(fmt.Sprintf("error extracting last insert id from result %v", err))
-		lastInsertId = -1
-		rerr = fmt.Errorf("%w", err)
-	}
+		if !rows.Next() {
+			if err = rows.Err(); err != nil {
+				return err
+			}
+			return sql.ErrNoRows
+		}
 
-	r := Result{
-		LastInsertId: lastInsertId,
-		RowsAffected: rowsAffected,
-	}
+		if err = rows.Scan(dest...); err != nil {
+			return fmt.Errorf("func ScanValues() failed to scan: %w", err)
+		}
 
-	return r, rerr
-}
+		if rows.Next() {
+			return errors.New("func ScanValues() query returned more than one row")
+		}
 
-type IntegerType interface {
-	~int8 | ~int16 | ~int32 | ~int64
+		return rows.Err()
+	})
 }
 
-type FloatType interface {
-	~float64 | ~float32
-}
+// scanRows calls fn with a RowMap for every row in rows, stopping at the first
+// error, and closes rows when done.
+func (repo repository[T]) scanRows(rows *sql.Rows, fn func(rowMap *RowMap) error) error {
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			slog.Error("error closing rows %w", "err", err)
+		}
+	}()
 
-type RowMap struct {
-	m map[string]any
-	// Errors is for the convenience of collecting any errors during
-	// the reading of a RowMap's convenience functions such as
-	// the Int* functions, String(), Bool, Bytes. These functions
-	// will store any errors that might have been encountered during
-	// these calls.
-	// TODO, explain more... it's so you don't have to keep checking errors
-	// durning the mapping process, just return: if r.errors != nil { }
-	errors []error
-}
+	cols, err := rows.Columns()
 
-type Result struct {
-	RowsAffected int64
-	LastInsertId int64
-}
+	if err != nil {
+		return err
+	}
 
-func toMap(cols []string, values []any) *RowMap {
-	rowMap := make(map[string]any, len(cols))
+	// keys are the names the RowMap is keyed by, cols stay as the query
+	// returned them for matching WithStreamColumns
+	keys := cols
+	if repo.options.lowercaseColumns {
+		keys = make([]string, len(cols))
+		for i, col := range cols {
+			keys[i] = strings.ToLower(col)
+		}
+	}
 
-	for i, col := range cols {
-		rowMap[col] = values[i]
+	if err = checkDuplicateColumns(keys); err != nil {
+		return err
 	}
 
-	return &RowMap{
-		m: rowMap,
+	// the index is the same for every row, so it is only built once per query
+	var fold map[string]string
+	if repo.options.caseInsensitive {
+		fold = foldIndex(keys)
+	}
+
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(values))
+	count := 0
+
+	// streamed columns scan into RawBytes, the slice for the other columns is nil
+	var raws []sql.RawBytes
+	if len(repo.options.streamColumns) > 0 {
+		raws = make([]sql.RawBytes, len(cols))
+	}
+
+	for rows.Next() {
+		count++
+		if repo.options.maxRows > 0 && count > repo.options.maxRows {
+			return fmt.Errorf("%w, the limit is %d", ErrTooManyRows, repo.options.maxRows)
+		}
+
+		// Take addresses directly in the Scan call
+		for i := range values {
+			if raws != nil && repo.options.streamColumns[cols[i]] {
+				ptrs[i] = &raws[i]
+			} else {
+				ptrs[i] = &values[i]
+			}
+		}
+
+		if err = rows.Scan(ptrs...); err != nil {
+			return err
+		}
+
+		for i := range raws {
+			if repo.options.streamColumns[cols[i]] {
+				values[i] = raws[i]
+			}
+		}
+
+		rowMap := toMap(keys, values)
+		rowMap.fold = fold
+		rowMap.loc = repo.options.timeLocation
+		rowMap.nullStringError = repo.options.nullStringError
+		rowMap.converters = repo.options.converters
+
+		if err = fn(rowMap); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// MapRowsNStruct is MapRowsN with the named parameters taken from the exported
+// fields of entity, a struct or a pointer to one. A field is bound to the
+// parameter named by its db tag, or by the field name when it has no tag, so
+// an Artist with a `db:"name"` field supplies :name.
+func (repo repository[T]) MapRowsNStruct(
+	ctx context.Context,
+	sql string,
+	entity any,
+	mapFunc MapFunc[T]) ([]*T, error) {
+
+	args, err := structArgs(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.MapRowsN(ctx, sql, args, mapFunc)
+}
+
+// Aggregate executes a query expected to return exactly one row, such as
+// "select count(*), avg(UnitPrice) from Track", and scans its columns into the
+// exported fields of the struct T by position rather than by column name, so a
+// one-off aggregate needs neither aliases nor a mapper. The number of columns
+// must match the number of exported fields.
+func (repo repository[T]) Aggregate(ctx context.Context, sql string, args []any) (*T, error) {
+	t := new(T)
+	rv := reflect.ValueOf(t).Elem()
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("func Aggregate() requires a struct type, %v is a %v", rv.Type(), rv.Kind())
+	}
+
+	var dest []any
+	for i := range rv.NumField() {
+		if rv.Type().Field(i).IsExported() {
+			dest = append(dest, rv.Field(i).Addr().Interface())
+		}
+	}
+
+	if err := repo.ScanValues(ctx, sql, args, dest...); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Query executes the query and returns a RowMap for every row, for callers
+// which want the raw values without defining a T and mapper, such as ad-hoc
+// tooling or generic JSON responses. Columns named WithStreamColumns are
+// copied, by name and by index, as the maps outlive the rows.
+func (repo repository[T]) Query(
+	ctx context.Context,
+	query string,
+	args []any,
+) ([]*RowMap, error) {
+	var results []*RowMap
+
+	err := repo.eachRow(ctx, query, args, func(rowMap *RowMap) error {
+		for k, v := range rowMap.m {
+			if raw, ok := v.(sql.RawBytes); ok {
+				rowMap.m[k] = bytes.Clone(raw)
+			}
+		}
+		for i, v := range rowMap.values {
+			if raw, ok := v.(sql.RawBytes); ok {
+				rowMap.values[i] = bytes.Clone(raw)
+			}
+		}
+		results = append(results, rowMap)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Query resulted in %d row(s)", "grepo", len(results))
+
+	return results, nil
+}
+
+// QueryN is Query with named parameters.
+func (repo repository[T]) QueryN(
+	ctx context.Context,
+	sql string,
+	args map[string]any) ([]*RowMap, error) {
+
+	query, newArgs, err := repo.bind(sql, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.Query(ctx, query, newArgs)
+}
+
+func (repo repository[T]) MapRowsN(
+	ctx context.Context,
+	sql string,
+	args map[string]any,
+	mapFunc MapFunc[T]) ([]*T, error) {
+
+	query, newArgs, err := repo.bind(sql, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := repo.MapRows(ctx, query, newArgs, mapFunc)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to execute query '%s' with parameters %v", query, redactNamed(args, repo.options.unredactedArgs)))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// bindNamed rewrites the named parameters within sql to positional placeholders
+// and returns the rewritten query along with the flattened arguments.
+// bind is bindNamed which, when the repository was created WithStrictParams,
+// first refuses args which no :name token in sql refers to.
+func (repo repository[T]) bind(sql string, args map[string]any) (string, []any, error) {
+	if repo.options.strictParams {
+		if unused := unusedParams(sql, args); len(unused) > 0 {
+			return "", nil, fmt.Errorf("parameter(s) %v are not referenced by the query", unused)
+		}
+	}
+
+	return bindNamedWith(repo.dialect, sql, args, 1)
+}
+
+// unusedParams returns, sorted, the keys of args which do not match a :name
+// token in sql. Keys may be given with or without the : prefix.
+func unusedParams(sql string, args map[string]any) []string {
+	tokens := make(map[string]bool)
+	for _, token := range re.FindAllString(sql, -1) {
+		if !isCast(token) {
+			tokens[token] = true
+		}
+	}
+
+	var unused []string
+	for k := range args {
+		if !tokens[":"+strings.TrimPrefix(k, ":")] {
+			unused = append(unused, k)
+		}
+	}
+
+	slices.Sort(unused)
+	return unused
+}
+
+func bindNamed(sql string, args map[string]any) (string, []any, error) {
+	return bindNamedWith(PostgresDialect{}, sql, args, 1)
+}
+
+// BindNamed rewrites the named parameters in sql to positional placeholders
+// numbered from start and returns the flattened args in placeholder order. A
+// start greater than 1 lets a fragment be composed after start-1 args which
+// have already been bound, for example binding "name = :name" with start 3
+// produces "name = $3". The placeholders are always in the $n form, which
+// Postgres and SQLite both accept, use BindNamedDialect for a fragment joined to
+// a query bound by a SQLite or MySQL repository.
+func BindNamed(sql string, args map[string]any, start int) (string, []any, error) {
+	return BindNamedDialect(PostgresDialect{}, sql, args, start)
+}
+
+// BindNamedDialect is BindNamed with the placeholders of d, such as ?3 for
+// SQLite. MySQL placeholders are not numbered, so start only has to be valid
+// and a name used twice binds its args twice.
+func BindNamedDialect(d Dialect, sql string, args map[string]any, start int) (string, []any, error) {
+	if start < 1 {
+		return "", nil, fmt.Errorf("placeholder start %d must be at least 1", start)
+	}
+
+	return bindNamedWith(d, sql, args, start)
+}
+
+// bindNamedWith rewrites the named parameters in sql to the placeholders of d
+// numbered from start and returns the flattened args in placeholder order.
+func bindNamedWith(d Dialect, sql string, args map[string]any, start int) (string, []any, error) {
+	entries := namedParameters(sql, args)
+
+	if len(entries) > 0 {
+		if positional := positionalPlaceholders(d, sql); len(positional) > 0 {
+			return "", nil, fmt.Errorf("query mixes positional placeholder(s) %v with named parameters, use only :name parameters", positional)
+		}
+	}
+	if err := checkArgCount(entries, flattenArgs(entries)); err != nil {
+		return "", nil, err
+	}
+
+	if !numbered(d) {
+		query, newArgs, err := substituteOrdered(d, sql, entries, start)
+		if err != nil {
+			return "", nil, fmt.Errorf("substitution of named parameters failed %w", err)
+		}
+		return query, newArgs, nil
+	}
+
+	query, err := substituteFrom(d, sql, entries, start)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("substitution of named parameters failed %w", err)
+	}
+
+	return query, flattenArgs(entries), nil
+}
+
+// placeholder is a positional placeholder found in a query.
+type placeholder struct {
+	// text is the placeholder as written, such as $2, ?2 or ?
+	text string
+	// start and end are the byte offsets of text within the query
+	start, end int
+	// n is the number of a $n or ?n placeholder, zero for a bare ?
+	n int
+}
+
+// placeholderSyntax is which placeholders a scan of a query recognises.
+type placeholderSyntax struct {
+	// dollar recognises $n
+	dollar bool
+	// question recognises ? and ?n
+	question bool
+	// dollarQuotes skips Postgres dollar-quoted strings such as $$text$$
+	dollarQuotes bool
+}
+
+// syntaxOf returns the placeholders understood by d. Postgres has only $n, a
+// ? is one of its jsonb operators. SQLite accepts $n as well as ? and ?n, and
+// MySQL has only ?.
+func syntaxOf(d Dialect) placeholderSyntax {
+	if d == nil {
+		d = PostgresDialect{}
+	}
+
+	first := d.Placeholder(1)
+	if strings.HasPrefix(first, "$") {
+		return placeholderSyntax{dollar: true, dollarQuotes: true}
+	}
+	return placeholderSyntax{dollar: numbered(d), question: true}
+}
+
+// numbered reports whether d numbers its placeholders, so a placeholder may
+// be repeated to bind the same arg twice, rather than binding in order of
+// appearance.
+func numbered(d Dialect) bool {
+	return d.Placeholder(1) != d.Placeholder(2)
+}
+
+// scanPlaceholders returns the placeholders of syntax in query, skipping string
+// literals, quoted identifiers, comments and, when enabled, dollar-quoted
+// strings.
+func scanPlaceholders(query string, syntax placeholderSyntax) []placeholder {
+	var found []placeholder
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipPast(query, i+1, string(c))
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			i = skipPast(query, i+2, "\n")
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			i = skipPast(query, i+2, "*/")
+		case c == '$' && syntax.dollarQuotes && dollarTag(query[i:]) != "":
+			tag := dollarTag(query[i:])
+			i = skipPast(query, i+len(tag), tag)
+		case (c == '$' && syntax.dollar) || (c == '?' && syntax.question):
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if c == '$' && j == i+1 {
+				continue
+			}
+			n, _ := strconv.Atoi(query[i+1 : j])
+			found = append(found, placeholder{text: query[i:j], start: i, end: j, n: n})
+			i = j - 1
+		}
+	}
+
+	return found
+}
+
+// skipPast returns the offset of the last byte of the first end at or after
+// from, or of the last byte of query when end does not appear.
+func skipPast(query string, from int, end string) int {
+	if from > len(query) {
+		return len(query)
+	}
+	if k := strings.Index(query[from:], end); k >= 0 {
+		return from + k + len(end) - 1
+	}
+	return len(query)
+}
+
+// dollarTag returns the opening tag of a Postgres dollar-quoted string at the
+// start of s, such as $$ or $body$, or "" when there is none. A tag cannot
+// start with a digit, so $1 is never one.
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		c := s[j]
+		switch {
+		case c == '$':
+			return s[:j+1]
+		case c == '_' || unicode.IsLetter(rune(c)) || (j > 1 && c >= '0' && c <= '9'):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// positionalPlaceholders returns the ?, ?n and $n placeholders in sql outside of
+// string literals, quoted identifiers and comments, whichever the dialect d.
+// Named parameters are numbered without regard to these, so a query using both
+// would bind the wrong args. The Postgres jsonb operators ?, ?| and ?& are
+// indistinguishable from a placeholder, in a query with named parameters use
+// jsonb_exists, jsonb_exists_any and jsonb_exists_all instead.
+func positionalPlaceholders(d Dialect, sql string) []string {
+	syntax := syntaxOf(d)
+	syntax.dollar, syntax.question = true, true
+
+	var found []string
+	for _, p := range scanPlaceholders(sql, syntax) {
+		found = append(found, p.text)
+	}
+	return found
+}
+
+// ErrTooManyParams is returned when a query, once its slices are expanded,
+// binds more args than the limit set WithMaxParams.
+var ErrTooManyParams = errors.New("query binds too many parameters")
+
+// checkArgs verifies the args of an expanded query are within the repository's
+// parameter limit and match its placeholders.
+func (repo repository[T]) checkArgs(query string, args []any) error {
+	limit := repo.options.maxParams
+	if limit <= 0 {
+		limit = maxParamsOf(repo.dialect)
+	}
+
+	if len(args) > limit {
+		return fmt.Errorf("%w, it binds %d and the limit is %d, split a large IN clause with ChunkIN", ErrTooManyParams, len(args), limit)
+	}
+
+	return checkPlaceholders(repo.dialect, query, args)
+}
+
+// checkPlaceholders compares the placeholders of d in a positional query with
+// the number of args, after slices were expanded, so a mismatch is reported by
+// grepo rather than as an opaque driver error. Numbered placeholders may be
+// repeated, so $1 = $1 takes one arg, and they need as many args as the highest
+// number. Unnumbered ? placeholders take one each. A ? is never a placeholder
+// with Postgres, where it is a jsonb operator, and a SQLite query mixing ?n with
+// ? is left to the driver.
+func checkPlaceholders(d Dialect, query string, args []any) error {
+	highest, bare := 0, 0
+
+	for _, p := range scanPlaceholders(query, syntaxOf(d)) {
+		if p.n == 0 {
+			bare++
+		} else if p.n > highest {
+			highest = p.n
+		}
+	}
+
+	want := highest
+	switch {
+	case highest == 0:
+		want = bare
+	case bare > 0:
+		return nil
+	}
+
+	if want != len(args) {
+		return fmt.Errorf("query has %d placeholder(s) but %d argument(s) were supplied", want, len(args))
+	}
+
+	return nil
+}
+
+// checkArgCount verifies that the number of placeholders generated by substitute
+// matches the number of arguments produced by flattenArgs. The two are computed
+// independently, so a disagreement would otherwise only surface as an opaque
+// driver error.
+func checkArgCount(entries map[string]paramEntry, args []any) error {
+	placeholders := 0
+	for _, pe := range entries {
+		placeholders += pe.len
+	}
+
+	if placeholders == len(args) {
+		return nil
+	}
+
+	for _, pe := range slices.SortedFunc(maps.Values(entries), paramSortFunc) {
+		if n := argCount(pe); n != pe.len {
+			return fmt.Errorf("parameter %s generated %d placeholder(s) but supplied %d argument(s)", pe.name, pe.len, n)
+		}
+	}
+
+	return fmt.Errorf("query generated %d placeholder(s) but %d argument(s) were supplied", placeholders, len(args))
+}
+
+// argCount returns the number of positional arguments flattenArgs produces for v.
+func argCount(pe paramEntry) int {
+	rv, ok := expandable(pe.val)
+	if !ok {
+		return 1
+	}
+	if rv.IsNil() {
+		return 0
+	}
+	if pe.width == 0 {
+		return rv.Len()
+	}
+
+	n := 0
+	for i := 0; i < rv.Len(); i++ {
+		if vals, ok := tuple(rv.Index(i).Interface()); ok {
+			n += len(vals)
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// tuple returns the values of v when it is one group of a composite IN clause
+// such as "(a, b) in ( :pairs )", either a slice or a struct of exported fields.
+func tuple(v any) ([]any, bool) {
+	if rv, ok := expandable(v); ok {
+		vals := make([]any, rv.Len())
+		for i := range vals {
+			vals[i] = rv.Index(i).Interface()
+		}
+		return vals, true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	// structs like time.Time are single values as far as the driver is concerned
+	if _, ok := v.(driver.Valuer); ok {
+		return nil, false
+	}
+	if _, ok := v.(time.Time); ok {
+		return nil, false
+	}
+
+	var vals []any
+	for i := 0; i < rv.NumField(); i++ {
+		if rv.Type().Field(i).IsExported() {
+			vals = append(vals, rv.Field(i).Interface())
+		}
+	}
+	return vals, true
+}
+
+// expandable reports whether v is a slice that should be expanded into one
+// placeholder per element. []byte and driver.Valuer slices (such as pq.Array
+// types) are single values as far as the driver is concerned.
+func expandable(v any) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return rv, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return rv, false
+	}
+	if _, ok := v.(driver.Valuer); ok {
+		return rv, false
+	}
+	return rv, true
+}
+
+// expandPositional rewrites the placeholders of d in sql so every slice argument
+// gets one placeholder per element, renumbering the numbered placeholders that
+// follow, and returns the rewritten query with the flattened arguments. A bare
+// ? binds the arg after the highest one bound so far, the rule SQLite uses,
+// which for MySQL is simply the next one.
+func expandPositional(d Dialect, sql string, args []any) (string, []any, error) {
+	// start holds the new placeholder number for each original argument
+	start := make([]int, len(args))
+	width := make([]int, len(args))
+	newArgs := make([]any, 0, len(args))
+	expanded := false
+	position := 1
+
+	for i, arg := range args {
+		start[i] = position
+		width[i] = 1
+
+		rv, ok := expandable(arg)
+		if !ok {
+			newArgs = append(newArgs, arg)
+			position++
+			continue
+		}
+
+		if rv.IsNil() || rv.Len() == 0 {
+			return "", nil, fmt.Errorf("argument %d is an empty slice and cannot be expanded into an IN clause", i+1)
+		}
+
+		expanded = true
+		width[i] = rv.Len()
+		for ei := 0; ei < rv.Len(); ei++ {
+			newArgs = append(newArgs, rv.Index(ei).Interface())
+		}
+		position += rv.Len()
+	}
+
+	if !expanded {
+		return sql, args, nil
+	}
+
+	var b strings.Builder
+	last, highest := 0, 0
+
+	for _, p := range scanPlaceholders(sql, syntaxOf(d)) {
+		n := p.n
+		if n == 0 {
+			n = highest + 1
+		}
+		highest = max(highest, n)
+
+		// leave anything without a matching argument for the driver to report
+		if n > len(args) {
+			continue
+		}
+
+		positions := make([]string, width[n-1])
+		for pi := range positions {
+			if p.n == 0 {
+				positions[pi] = "?"
+			} else {
+				positions[pi] = p.text[:1] + strconv.Itoa(start[n-1]+pi)
+			}
+		}
+
+		b.WriteString(sql[last:p.start])
+		b.WriteString(strings.Join(positions, ", "))
+		last = p.end
+	}
+	b.WriteString(sql[last:])
+
+	return b.String(), newArgs, nil
+}
+
+func flattenArgs(entries map[string]paramEntry) []any {
+	var newArgs []any
+	// need the entries sorted by their position so they wind up in the correct place when
+	// executed
+	for _, pe := range slices.SortedFunc(maps.Values(entries), paramSortFunc) {
+		newArgs = append(newArgs, entryArgs(pe)...)
+	}
+
+	return newArgs
+}
+
+// entryArgs returns the positional args of one named parameter, an arg per
+// element of a slice and per value of each tuple of a composite IN clause.
+func entryArgs(pe paramEntry) []any {
+	var newArgs []any
+	switch v := pe.val.(type) {
+	default:
+		// Check if it's any kind of slice
+		if rv, ok := expandable(v); ok {
+			if rv.IsValid() && !rv.IsNil() {
+				for i := 0; i < rv.Len(); i++ {
+					elem := rv.Index(i)
+					if !elem.IsValid() {
+						continue
+					}
+					if vals, ok := tuple(elem.Interface()); ok && pe.width > 0 {
+						newArgs = append(newArgs, vals...)
+					} else {
+						newArgs = append(newArgs, elem.Interface())
+					}
+				}
+			}
+		} else {
+			newArgs = append(newArgs, pe.val)
+		}
+	}
+
+	return newArgs
+}
+
+// Execute performs the given query with args and returns a Result. Unless the
+// repository is already bound to a transaction (see WithTx), the statement runs
+// in its own transaction using the repository's TxOptions.
+func (repo repository[T]) Execute(
+	ctx context.Context,
+	sql string,
+	args []any) (Result, error) {
+
+	if err := checkQuery(sql); err != nil {
+		return Result{}, err
+	}
+
+	ctx, cancel := repo.withTimeout(ctx)
+	defer cancel()
+
+	sql, args, err := expandPositional(repo.dialect, sql, args)
+	if err != nil {
+		return Result{}, err
+	}
+	if err = repo.checkArgs(sql, args); err != nil {
+		return Result{}, err
+	}
+	args = repo.bindArgs(args)
+
+	if repo.tx != nil {
+		repo.logQuery(ctx, sql, args)
+		result, err := repo.tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			slog.Error(fmt.Sprintf("func Execute() errored on Exec %v", err))
+			return Result{}, fmt.Errorf("func Execute() errored on Exec: %w", repo.queryError(sql, args, err))
+		}
+		return repo.toResult(result)
+	}
+
+	tx, err := repo.database.BeginTx(ctx, repo.options.txOptions)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to begin a transaction Execute() %v", err))
+		return Result{}, fmt.Errorf("function Execute() errored on Exec %w", err)
+
+	}
+
+	repo.logQuery(ctx, sql, args)
+	result, err := tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		_ = tx.Rollback()
+		slog.Error(fmt.Sprintf("func Execute() errored on Exec %v", err))
+		return Result{}, fmt.Errorf("func Execute() errored on Exec: %w", repo.queryError(sql, args, err))
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("error executing commit in Execute()  %v", err))
+		return Result{}, fmt.Errorf("func Execute() failed during Commit: %w", err)
+	}
+
+	return repo.toResult(result)
+}
+
+// ExecuteN performs the given query with named parameters like Execute. The
+// parameters are bound the same way as MapRowsN, so a slice value such as the
+// ids in "delete from Track where TrackId in ( :ids )" becomes a placeholder
+// for each element rather than being joined into the statement.
+func (repo repository[T]) ExecuteN(
+	ctx context.Context,
+	sql string,
+	args map[string]any) (Result, error) {
+
+	query, newArgs, err := repo.bind(sql, args)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return repo.Execute(ctx, query, newArgs)
+}
+
+// ExecuteNoTx performs the given query with args directly against the database
+// in autocommit mode. This avoids the overhead of a transaction for single
+// statements and allows statements, such as some DDL, which a driver refuses to
+// run inside one. A repository bound to a transaction still uses it.
+func (repo repository[T]) ExecuteNoTx(
+	ctx context.Context,
+	sql string,
+	args []any) (Result, error) {
+
+	if err := checkQuery(sql); err != nil {
+		return Result{}, err
+	}
+
+	ctx, cancel := repo.withTimeout(ctx)
+	defer cancel()
+
+	sql, args, err := expandPositional(repo.dialect, sql, args)
+	if err != nil {
+		return Result{}, err
+	}
+	if err = repo.checkArgs(sql, args); err != nil {
+		return Result{}, err
+	}
+	args = repo.bindArgs(args)
+
+	repo.logQuery(ctx, sql, args)
+	result, err := repo.conn().ExecContext(ctx, sql, args...)
+	if err != nil {
+		slog.Error(fmt.Sprintf("func ExecuteNoTx() errored on Exec %v", err))
+		return Result{}, fmt.Errorf("func ExecuteNoTx() errored on Exec: %w", repo.queryError(sql, args, err))
+	}
+
+	return repo.toResult(result)
+}
+
+// ExecuteScript runs each of statements, in order, within one transaction and
+// returns the Result of each. If any statement fails the whole script is rolled
+// back and the error identifies the failing statement by its index.
+func (repo repository[T]) ExecuteScript(ctx context.Context, statements []string) ([]Result, error) {
+	results := make([]Result, 0, len(statements))
+
+	err := repo.WithTx(ctx, func(tx Repository[T]) error {
+		for i, statement := range statements {
+			result, err := tx.Execute(ctx, statement, nil)
+			if err != nil {
+				return fmt.Errorf("func ExecuteScript() failed on statement %d: %w", i, err)
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// WithTx begins a transaction using the repository's TxOptions and calls fn
+// with a repository bound to it. The transaction is committed if fn returns
+// nil and rolled back otherwise. Calling WithTx on a repository that is already
+// bound to a transaction simply joins the outer transaction.
+func (repo repository[T]) WithTx(ctx context.Context, fn func(tx Repository[T]) error) error {
+	if repo.tx != nil {
+		return fn(repo)
+	}
+
+	tx, err := repo.database.BeginTx(ctx, repo.options.txOptions)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to begin a transaction WithTx() %v", err))
+		return fmt.Errorf("func WithTx() failed to begin a transaction: %w", err)
+	}
+
+	bound := repo
+	bound.tx = tx
+
+	if err = fn(bound); err != nil {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			slog.Error(fmt.Sprintf("error executing rollback in WithTx() %v", rerr))
+			return errors.Join(err, rerr)
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(fmt.Sprintf("error executing commit in WithTx() %v", err))
+		return fmt.Errorf("func WithTx() failed during Commit: %w", err)
+	}
+
+	return nil
+}
+
+// Atomic runs fn in a transaction on repo like WithTx and returns the value fn
+// computed, for read-modify-write work such as reading a balance, adjusting it
+// and returning the new balance. The transaction is committed if fn returns nil
+// and rolled back otherwise, in which case the zero R is returned.
+func Atomic[R, T any](ctx context.Context, repo Repository[T], fn func(tx Repository[T]) (R, error)) (R, error) {
+	var result R
+
+	err := repo.WithTx(ctx, func(tx Repository[T]) error {
+		r, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		result = r
+		return nil
+	})
+
+	if err != nil {
+		var zero R
+		return zero, err
+	}
+
+	return result, nil
+}
+
+// toResult extracts the rows affected and last insert id from a sql.Result.
+// LastInsertId is left at 0 when the dialect does not support it, rather than
+// reporting an error for every insert.
+func (repo repository[T]) toResult(result sql.Result) (Result, error) {
+	var lastInsertId int64
+	var rowsAffected int64
+
+	rowsAffected, rerr := result.RowsAffected()
+
+	// There is some wonky attempts at capturing some errors here, just in case
+	// one of the two result calls causes an error. We may not want to fail
+	// completely. TODO need error types.
+	if rerr != nil {
+		slog.Error(fmt.Sprintf("error extracting rows affected from result %v", rerr))
+		rowsAffected = -1
+	}
+
+	if repo.dialect.SupportsLastInsertId() {
+		var err error
+		lastInsertId, err = result.LastInsertId()
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("error extracting last insert id from result %v", err))
+			lastInsertId = -1
+			rerr = fmt.Errorf("%w", err)
+		}
+	}
+
+	r := Result{
+		LastInsertId: lastInsertId,
+		RowsAffected: rowsAffected,
+	}
+
+	return r, rerr
+}
+
+type IntegerType interface {
+	~int8 | ~int16 | ~int32 | ~int64
+}
+
+type UintType interface {
+	~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uint
+}
+
+type FloatType interface {
+	~float64 | ~float32
+}
+
+type RowMap struct {
+	m map[string]any
+	// Errors is for the convenience of collecting any errors during
+	// the reading of a RowMap's convenience functions such as
+	// the Int* functions, String(), Bool, Bytes. These functions
+	// will store any errors that might have been encountered during
+	// these calls.
+	// TODO, explain more... it's so you don't have to keep checking errors
+	// durning the mapping process, just return: if r.errors != nil { }
+	errors []error
+	// cols are the column names in the order the query returned them
+	cols []string
+	// values are the column values in the same order as cols
+	values []any
+	// missing records the keys requested by a mapper which are not in the row
+	missing []string
+	// fold is the lowercased column index used for case-insensitive lookups,
+	// nil when lookups are exact
+	fold map[string]string
+	// loc is the location Time converts to, nil leaves times as read
+	loc *time.Location
+	// nullStringError records an error when String reads SQL NULL
+	nullStringError bool
+	// converters are the functions registered for Convert
+	converters converters
+}
+
+type Result struct {
+	RowsAffected int64
+	LastInsertId int64
+}
+
+func toMap(cols []string, values []any) *RowMap {
+	rowMap := make(map[string]any, len(cols))
+
+	for i, col := range cols {
+		rowMap[col] = values[i]
+	}
+
+	return &RowMap{
+		m:    rowMap,
+		cols: cols,
+		// scanRows reuses its values for the next row
+		values: slices.Clone(values),
 	}
 }
 
-// toInteger is a generic function that handles conversion to any supported integer type
+// toInteger is a generic function that handles conversion to any supported integer type.
+// A value which does not fit within T is an error rather than being truncated.
 func toInteger[T IntegerType](v any) (T, error) {
+	wide, err := toInteger64(v)
+	if err != nil {
+		return 0, err
+	}
+
+	t := T(wide)
+	if int64(t) != wide {
+		return 0, fmt.Errorf("value %d overflows %T", wide, t)
+	}
+
+	return t, nil
+}
+
+// truncInteger converts v to T like toInteger, but truncates a value which
+// does not fit rather than returning an error.
+func truncInteger[T IntegerType](v any) (T, error) {
+	wide, err := toInteger64(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return T(wide), nil
+}
+
+func toInteger64(v any) (int64, error) {
 	switch val := v.(type) {
 	case int64:
-		return T(val), nil
+		return val, nil
 	case int32:
-		return T(val), nil
+		return int64(val), nil
 	case int16:
-		return T(val), nil
+		return int64(val), nil
 	case int8:
-		return T(val), nil
+		return int64(val), nil
 	default:
 		return 0, fmt.Errorf("cannot convert %v to an integer type", v)
 	}
 }
 
-func toFloat[T FloatType](v any) (T, error) {
-	switch val := v.(type) {
-	case float32:
-		return T(val), nil
-	case float64:
-		return T(val), nil
-	default:
-		return 0, fmt.Errorf("cannot convert %v to an float type", v)
+// toUint converts an unsigned or signed integer v to T, refusing a negative
+// value and one which does not fit within T.
+func toUint[T UintType](v any) (T, error) {
+	var wide uint64
+
+	switch val := v.(type) {
+	case uint64:
+		wide = val
+	case uint32:
+		wide = uint64(val)
+	case uint16:
+		wide = uint64(val)
+	case uint8:
+		wide = uint64(val)
+	case uint:
+		wide = uint64(val)
+	default:
+		i, err := toInteger64(v)
+		if err != nil {
+			return 0, err
+		}
+		if i < 0 {
+			return 0, fmt.Errorf("negative value %d cannot be converted to an unsigned type", i)
+		}
+		wide = uint64(i)
+	}
+
+	t := T(wide)
+	if uint64(t) != wide {
+		return 0, fmt.Errorf("value %d overflows %T", wide, t)
+	}
+
+	return t, nil
+}
+
+func toFloat[T FloatType](v any) (T, error) {
+	switch val := v.(type) {
+	case float32:
+		return T(val), nil
+	case float64:
+		return T(val), nil
+	default:
+		return 0, fmt.Errorf("cannot convert %v to an float type", v)
+	}
+}
+
+func (m *RowMap) addErr(e error) {
+	m.errors = append(m.errors, e)
+}
+
+func (m *RowMap) Err() error {
+	return errors.Join(m.errors...)
+}
+
+// Raw returns the value stored for the key (k) as the driver returned it, and
+// whether the row has that column, without recording any error. It is the
+// escape hatch for driver types the typed accessors do not convert. Columns
+// named WithStreamColumns are returned as sql.RawBytes, valid only until the
+// next row.
+func (m *RowMap) Raw(k string) (any, bool) {
+	if v, ok := m.m[k]; ok {
+		return v, true
+	}
+
+	if m.fold != nil {
+		if col, ok := m.fold[strings.ToLower(k)]; ok {
+			return m.m[col], true
+		}
+	}
+
+	return nil, false
+}
+
+// ByIndex returns the value of the column at position i, counting from 0 in
+// the order the query returned them, as the driver returned it, and whether
+// the row has that many columns. It reads computed columns such as count(*)
+// whose names differ between drivers, and records no error.
+func (m *RowMap) ByIndex(i int) (any, bool) {
+	if i < 0 || i >= len(m.values) {
+		return nil, false
+	}
+	return m.values[i], true
+}
+
+// at returns the value of the column at position i, recording an error when
+// there is no such column.
+func (m *RowMap) at(i int) (any, bool) {
+	v, ok := m.ByIndex(i)
+	if !ok {
+		m.addErr(fmt.Errorf("column index %d is out of range, the row has %d column(s) %v", i, len(m.values), m.cols))
+	}
+	return v, ok
+}
+
+// Int64At is Int64 for the column at position i, see ByIndex.
+func (m *RowMap) Int64At(i int) int64 {
+	val, ok := m.at(i)
+	if !ok || val == nil {
+		return 0
+	}
+
+	v, err := toInteger[int64](val)
+	if err != nil {
+		m.addErr(NewColReadError(fmt.Sprintf("[%d]", i), val, "int64"))
+		return 0
+	}
+	return v
+}
+
+// Float64At is Float64 for the column at position i, see ByIndex.
+func (m *RowMap) Float64At(i int) float64 {
+	val, ok := m.at(i)
+	if !ok || val == nil {
+		return 0
+	}
+
+	v, err := toFloat[float64](val)
+	if err != nil {
+		m.addErr(NewColReadError(fmt.Sprintf("[%d]", i), val, "float64"))
+		return 0
+	}
+	return v
+}
+
+// StringAt is String for the column at position i, see ByIndex.
+func (m *RowMap) StringAt(i int) string {
+	val, ok := m.at(i)
+	if !ok || val == nil {
+		return ""
+	}
+
+	v, isString := val.(string)
+	if !isString {
+		m.addErr(NewColReadError(fmt.Sprintf("[%d]", i), val, "string"))
+		return ""
+	}
+	return v
+}
+
+// ResetErrors clears the errors recorded so far, for mappers which probe
+// alternative column names and tolerate the lookups which fail. The probed keys
+// are forgotten as well, so they are not reported WithStrictColumns.
+func (m *RowMap) ResetErrors() {
+	m.errors = nil
+	m.missing = nil
+}
+
+// Keys returns the sorted names of the columns in the row.
+func (m *RowMap) Keys() []string {
+	return slices.Sorted(maps.Keys(m.m))
+}
+
+// ToMap returns a copy of the row's columns and values, for logging a row or
+// returning it as untyped JSON. Text which arrived as []byte is converted to a
+// string, binary values which are not valid UTF-8 are copied as []byte.
+// Changing the returned map does not affect the RowMap.
+func (m *RowMap) ToMap() map[string]any {
+	c := make(map[string]any, len(m.m))
+	for k, v := range m.m {
+		c[k] = normalizeValue(v)
+	}
+	return c
+}
+
+// MarshalJSON encodes the row as a JSON object with its columns in the order the
+// query returned them. Text which arrived as []byte is encoded as a string
+// rather than base64, numbers stay numbers and SQL NULL is null, so the rows
+// from Query can be written straight to an HTTP response.
+func (m *RowMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+
+	for i, col := range m.cols {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		key, err := json.Marshal(col)
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := json.Marshal(normalizeValue(m.m[col]))
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal column '%s': %w", col, err)
+		}
+
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// normalizeValue converts byte values to a string when they hold text and
+// copies them otherwise, other values are returned as they are.
+func normalizeValue(v any) any {
+	var b []byte
+	switch val := v.(type) {
+	case []byte:
+		b = val
+	case sql.RawBytes:
+		b = val
+	default:
+		return v
+	}
+
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return bytes.Clone(b)
+}
+
+func (m *RowMap) Apply(t *any) (*any, error) {
+	if err := m.Err(); m != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// try returns the value stored for the key (k), falling back to a case-insensitive
+// match when the repository was created WithCaseInsensitiveColumns.
+func (m *RowMap) try(k string) (any, error) {
+	if v, ok := m.m[k]; ok {
+		return v, nil
+	}
+
+	if m.fold != nil {
+		if col, ok := m.fold[strings.ToLower(k)]; ok {
+			return m.m[col], nil
+		}
+	}
+
+	m.missing = append(m.missing, k)
+	return nil, NewColMissingError(k, m.cols)
+}
+
+// checkDuplicateColumns refuses results with repeated column names, such as the
+// id columns of a join, as only one of them could be kept in the RowMap.
+func checkDuplicateColumns(cols []string) error {
+	seen := make(map[string]bool, len(cols))
+	var duplicates []string
+
+	for _, col := range cols {
+		if seen[col] && !slices.Contains(duplicates, col) {
+			duplicates = append(duplicates, col)
+		}
+		seen[col] = true
+	}
+
+	if len(duplicates) > 0 {
+		return fmt.Errorf("query returned duplicate column name(s) %v, alias the columns so each name is unique", duplicates)
+	}
+
+	return nil
+}
+
+// foldIndex maps the lowercased column names to the names the query returned.
+func foldIndex(cols []string) map[string]string {
+	fold := make(map[string]string, len(cols))
+	for _, col := range cols {
+		if _, exists := fold[strings.ToLower(col)]; !exists {
+			fold[strings.ToLower(col)] = col
+		}
+	}
+	return fold
+}
+
+func (c ColReadError) Error() string {
+	return fmt.Sprintf("cannot convert key '%s' value '%v' to '%s'", c.key, c.value, c.target)
+}
+
+func NewColReadError(key string, value any, target string) ColReadError {
+	return ColReadError{
+		key, value, target,
+	}
+}
+
+// ColMissingError is recorded when a key is requested which is not a column of
+// the row, as opposed to ColReadError which records a column whose value could
+// not be converted. Use errors.As to tell schema or typo problems apart from
+// type mismatches.
+type ColMissingError struct {
+	key     string
+	columns []string
+}
+
+func (c ColMissingError) Error() string {
+	return fmt.Sprintf("key '%s' does not exist in row map, available columns are %v", c.key, c.columns)
+}
+
+// Key returns the key which was requested.
+func (c ColMissingError) Key() string {
+	return c.key
+}
+
+// Columns returns the columns the row does have.
+func (c ColMissingError) Columns() []string {
+	return c.columns
+}
+
+func NewColMissingError(key string, columns []string) ColMissingError {
+	return ColMissingError{
+		key, columns,
+	}
+}
+
+// ScanCountError is returned by the positional scan APIs, ScanValues and
+// Aggregate, when the number of destinations does not match the number of
+// columns the query returned, which the driver would otherwise only report
+// generically once the row was scanned.
+type ScanCountError struct {
+	columns      []string
+	destinations int
+}
+
+func (s ScanCountError) Error() string {
+	return fmt.Sprintf("given %d scan destination(s) for %d column(s) %v", s.destinations, len(s.columns), s.columns)
+}
+
+// Columns returns the columns the query returned.
+func (s ScanCountError) Columns() []string {
+	return s.columns
+}
+
+// Destinations returns the number of destinations given.
+func (s ScanCountError) Destinations() int {
+	return s.destinations
+}
+
+func NewScanCountError(columns []string, destinations int) ScanCountError {
+	return ScanCountError{
+		columns, destinations,
 	}
 }
 
-func (m *RowMap) addErr(e error) {
-	m.errors = append(m.errors, e)
+// QueryError wraps an error returned by the driver with the statement as it
+// was sent, after named parameters and slices were expanded, and a view of
+// the bound args. The args are redacted to their types unless the repository
+// was created WithUnredactedArgs.
+type QueryError struct {
+	sql  string
+	args []string
+	err  error
 }
 
-func (m *RowMap) Err() error {
-	return errors.Join(m.errors...)
+func (q QueryError) Error() string {
+	return fmt.Sprintf("%v, sql: %s, args: %v", q.err, q.sql, q.args)
 }
 
-func (m *RowMap) Apply(t *any) (*any, error) {
-	if err := m.Err(); m != nil {
-		return nil, err
-	}
-	return t, nil
+// SQL returns the statement sent to the driver.
+func (q QueryError) SQL() string {
+	return q.sql
+}
+
+// Args returns the bound args, redacted unless WithUnredactedArgs was used.
+func (q QueryError) Args() []string {
+	return q.args
+}
+
+func (q QueryError) Unwrap() error {
+	return q.err
 }
 
-func (m *RowMap) try(k string) error {
-	if _, ok := m.m[k]; !ok {
-		return fmt.Errorf("key '%s' does not exist in row map", k)
+func NewQueryError(sql string, args []string, err error) QueryError {
+	return QueryError{
+		sql, args, err,
 	}
-	return nil
 }
 
-func (c ColReadError) Error() string {
-	return fmt.Sprintf("cannot convert key '%s' value '%v' to '%s'", c.key, c.value, c.target)
+// logQuery logs the statement as it is sent, after named parameters and slices
+// were expanded, and its args at debug level on the repository's logger. The
+// args are redacted according to the repository's options.
+func (repo repository[T]) logQuery(ctx context.Context, query string, args []any) {
+	logger := repo.logger()
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	logger.DebugContext(ctx, "executing query",
+		"sql", query,
+		"args", len(args),
+		"values", redactArgs(args, repo.options.unredactedArgs))
 }
 
-func NewColReadError(key string, value any, target string) ColReadError {
-	return ColReadError{
-		key, value, target,
+// logger returns the logger set WithLogger, or the default logger.
+func (repo repository[T]) logger() *slog.Logger {
+	if repo.options.logger != nil {
+		return repo.options.logger
 	}
+	return slog.Default()
+}
+
+// queryError wraps err in a QueryError for sql and args, redacting the args
+// according to the repository's options.
+func (repo repository[T]) queryError(sql string, args []any, err error) error {
+	return NewQueryError(sql, redactArgs(args, repo.options.unredactedArgs), err)
 }
 
+// String attempts to assert and return the value within
+// the RowMap with the provided key (k) as a string.
+// SQL NULL returns "" without recording an error unless the
+// repository was created WithNullStringError, a value of any
+// other type records a ColReadError.
 func (m *RowMap) String(k string) string {
-	err := m.try(k)
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return ""
 	}
 
-	switch v := m.m[k].(type) {
+	switch v := val.(type) {
 	case string:
 		return v
+	case nil:
+		if m.nullStringError {
+			m.addErr(NewColReadError(k, v, "string"))
+		}
+		return ""
 	default:
 		m.addErr(NewColReadError(k, v, "string"))
 		return ""
@@ -436,41 +2004,80 @@ func (m *RowMap) String(k string) string {
 
 // Int64 attempts to assert and return the value within
 // the RowMap with the provided key (k) as an int64.
-// If the original value is an in64, the value will be
-// truncated to an int64 (precision will be lost).
+// SQL NULL returns zero without recording an error, use Get
+// for a Nullable when the distinction matters.
 // If the assertion fails, then zero is returned.
 func (m *RowMap) Int64(k string) int64 {
 
-	err := m.try(k)
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return 0
 	}
 
-	if v, err := toInteger[int64](m.m[k]); err != nil {
-		m.addErr(NewColReadError(k, v, "int64"))
+	if val == nil {
+		return 0
+	}
+
+	if v, err := toInteger[int64](val); err != nil {
+		m.addErr(NewColReadError(k, val, "int64"))
 		return 0
 	} else {
 		return v
 	}
 }
 
+// Int attempts to assert and return the value within
+// the RowMap with the provided key (k) as an int, the
+// type most structs use for ids and counts.
+// On a 32-bit platform a value which does not fit within
+// an int records an error rather than truncating it.
+// SQL NULL returns zero without recording an error.
+// If the assertion fails, then zero is returned.
+func (m *RowMap) Int(k string) int {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return 0
+	}
+
+	if val == nil {
+		return 0
+	}
+
+	v, err := toInteger[int64](val)
+	if err != nil || int64(int(v)) != v {
+		m.addErr(NewColReadError(k, val, "int"))
+		return 0
+	}
+
+	return int(v)
+}
+
 // Int32 attempts to assert and return the value within
 // the RowMap with the provided key (k) as an int32.
-// If the original value is an in64, the value will be
-// truncated to an int32 (precision will be lost).
+// If the original value does not fit within an int32,
+// an error is recorded rather than truncating it, use
+// Int32Trunc to truncate instead.
+// SQL NULL returns zero without recording an error, use Get
+// for a Nullable when the distinction matters.
 // If the assertion fails, then zero is returned.
 func (m *RowMap) Int32(k string) int32 {
-	err := m.try(k)
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return 0
 	}
 
-	if v, err := toInteger[int32](m.m[k]); err != nil {
-		m.addErr(NewColReadError(k, v, "int32"))
+	if val == nil {
+		return 0
+	}
+
+	if v, err := toInteger[int32](val); err != nil {
+		m.addErr(NewColReadError(k, val, "int32"))
 		return 0
 	} else {
 		return v
@@ -479,19 +2086,26 @@ func (m *RowMap) Int32(k string) int32 {
 
 // Int16 attempts to assert and return the value within
 // the RowMap with the provided key (k) as an int16.
-// If the original value is an in64, the value will be
-// truncated to an int16 (precision will be lost).
+// If the original value does not fit within an int16,
+// an error is recorded rather than truncating it, use
+// Int16Trunc to truncate instead.
+// SQL NULL returns zero without recording an error, use Get
+// for a Nullable when the distinction matters.
 // If the assertion fails, then zero is returned.
 func (m *RowMap) Int16(k string) int16 {
-	err := m.try(k)
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return 0
 	}
 
-	if v, err := toInteger[int16](m.m[k]); err != nil {
-		m.addErr(NewColReadError(k, v, "int16"))
+	if val == nil {
+		return 0
+	}
+
+	if v, err := toInteger[int16](val); err != nil {
+		m.addErr(NewColReadError(k, val, "int16"))
 		return 0
 	} else {
 		return v
@@ -500,38 +2114,215 @@ func (m *RowMap) Int16(k string) int16 {
 
 // Int8 attempts to assert and return the value within
 // the RowMap with the provided key (k) as an int8.
-// If the original value is anything larger, the value will be
-// truncated to an int8 (precision will be lost).
+// If the original value does not fit within an int8,
+// an error is recorded rather than truncating it, use
+// Int8Trunc to truncate instead.
+// SQL NULL returns zero without recording an error, use Get
+// for a Nullable when the distinction matters.
 // If the assertion fails, then zero is returned.
 func (m *RowMap) Int8(k string) int8 {
-	err := m.try(k)
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return 0
+	}
+
+	if val == nil {
+		return 0
+	}
+
+	if v, err := toInteger[int8](val); err != nil {
+		m.addErr(NewColReadError(k, val, "int8"))
+		return 0
+	} else {
+		return v
+	}
+}
+
+// Integer reads the value within the RowMap with the provided key (k)
+// as T, which may be a named integer type such as an enum backed by
+// a smallint column, so it is populated without a cast. A value which
+// does not fit within T records an error like Int8 does.
+// SQL NULL returns zero without recording an error.
+func Integer[T IntegerType](m *RowMap, k string) T {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return 0
+	}
+
+	if val == nil {
+		return 0
+	}
+
+	v, err := toInteger[T](val)
+	if err != nil {
+		m.addErr(NewColReadError(k, val, fmt.Sprintf("%T", v)))
+		return 0
+	}
+
+	return v
+}
+
+// Int32Trunc attempts to assert and return the value within
+// the RowMap with the provided key (k) as an int32.
+// Unlike Int32, a value which does not fit within an int32
+// is silently truncated to its low bits, the same as a
+// Go conversion, rather than recording an error.
+// SQL NULL returns zero without recording an error.
+// If the assertion fails, then zero is returned.
+func (m *RowMap) Int32Trunc(k string) int32 {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return 0
+	}
+
+	if val == nil {
+		return 0
+	}
+
+	if v, err := truncInteger[int32](val); err != nil {
+		m.addErr(NewColReadError(k, val, "int32"))
+		return 0
+	} else {
+		return v
+	}
+}
+
+// Int16Trunc attempts to assert and return the value within
+// the RowMap with the provided key (k) as an int16.
+// Unlike Int16, a value which does not fit within an int16
+// is silently truncated to its low bits, the same as a
+// Go conversion, rather than recording an error.
+// SQL NULL returns zero without recording an error.
+// If the assertion fails, then zero is returned.
+func (m *RowMap) Int16Trunc(k string) int16 {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return 0
+	}
+
+	if val == nil {
+		return 0
+	}
+
+	if v, err := truncInteger[int16](val); err != nil {
+		m.addErr(NewColReadError(k, val, "int16"))
+		return 0
+	} else {
+		return v
+	}
+}
+
+// Int8Trunc attempts to assert and return the value within
+// the RowMap with the provided key (k) as an int8.
+// Unlike Int8, a value which does not fit within an int8
+// is silently truncated to its low bits, the same as a
+// Go conversion, rather than recording an error.
+// SQL NULL returns zero without recording an error.
+// If the assertion fails, then zero is returned.
+func (m *RowMap) Int8Trunc(k string) int8 {
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return 0
 	}
 
-	if v, err := toInteger[int8](m.m[k]); err != nil {
-		m.addErr(NewColReadError(k, v, "int8"))
+	if val == nil {
+		return 0
+	}
+
+	if v, err := truncInteger[int8](val); err != nil {
+		m.addErr(NewColReadError(k, val, "int8"))
 		return 0
 	} else {
 		return v
 	}
 }
 
+// Uint64 attempts to assert and return the value within
+// the RowMap with the provided key (k) as a uint64, for
+// unsigned columns such as MySQL's BIGINT UNSIGNED. A
+// negative value records an error rather than wrapping.
+// SQL NULL returns zero without recording an error.
+// If the assertion fails, then zero is returned.
+func (m *RowMap) Uint64(k string) uint64 {
+	return readUint[uint64](m, k, "uint64")
+}
+
+// Uint32 is Uint64 for a uint32, a value which does not
+// fit within a uint32 records an error.
+func (m *RowMap) Uint32(k string) uint32 {
+	return readUint[uint32](m, k, "uint32")
+}
+
+// Uint16 is Uint64 for a uint16, a value which does not
+// fit within a uint16 records an error.
+func (m *RowMap) Uint16(k string) uint16 {
+	return readUint[uint16](m, k, "uint16")
+}
+
+// Uint8 is Uint64 for a uint8, a value which does not
+// fit within a uint8 records an error.
+func (m *RowMap) Uint8(k string) uint8 {
+	return readUint[uint8](m, k, "uint8")
+}
+
+// Uint is Uint64 for a platform uint, a value which does
+// not fit within a uint records an error.
+func (m *RowMap) Uint(k string) uint {
+	return readUint[uint](m, k, "uint")
+}
+
+// readUint reads the value for the key (k) as T for the Uint accessors,
+// recording a ColReadError for target when it cannot.
+func readUint[T UintType](m *RowMap, k string, target string) T {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return 0
+	}
+
+	if val == nil {
+		return 0
+	}
+
+	v, err := toUint[T](val)
+	if err != nil {
+		m.addErr(NewColReadError(k, val, target))
+		return 0
+	}
+
+	return v
+}
+
 // Float64 attempts to assert and return the value within
 // the RowMap with the provided key (k) as a float64.
+// SQL NULL returns zero without recording an error, use Get
+// for a Nullable when the distinction matters.
 // If the assertion fails, then zero is returned.
 func (m *RowMap) Float64(k string) float64 {
-	err := m.try(k)
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return 0
 	}
 
-	if v, err := toFloat[float64](m.m[k]); err != nil {
-		m.addErr(NewColReadError(k, v, "float64"))
+	if val == nil {
+		return 0
+	}
+
+	if v, err := toFloat[float64](val); err != nil {
+		m.addErr(NewColReadError(k, val, "float64"))
 		return 0
 	} else {
 		return v
@@ -540,17 +2331,23 @@ func (m *RowMap) Float64(k string) float64 {
 
 // Float32 attempts to assert and return the value within
 // the RowMap with the provided key (k) as a float64.
+// SQL NULL returns zero without recording an error, use Get
+// for a Nullable when the distinction matters.
 // If the assertion fails, then zero is returned.
 func (m *RowMap) Float32(k string) float32 {
-	err := m.try(k)
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return 0
 	}
 
-	if v, err := toFloat[float32](m.m[k]); err != nil {
-		m.addErr(NewColReadError(k, v, "float32"))
+	if val == nil {
+		return 0
+	}
+
+	if v, err := toFloat[float32](val); err != nil {
+		m.addErr(NewColReadError(k, val, "float32"))
 		return 0
 	} else {
 		return v
@@ -559,19 +2356,37 @@ func (m *RowMap) Float32(k string) float32 {
 
 // Bool attempt to assert and return the value within
 // the RowMap with the provided key (k) as a bool.
+// Integers are true when non-zero, strings and []byte
+// are parsed, accepting t, f, true, false, 1 and 0.
+// SQL NULL returns false without recording an error, use Get
+// for a Nullable when the distinction matters.
 // If the assertion fails, then false is returned.
 func (m *RowMap) Bool(k string) bool {
-	err := m.try(k)
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return false
 	}
 
-	switch v := m.m[k].(type) {
+	if val == nil {
+		return false
+	}
+
+	switch v := val.(type) {
+	case bool:
+		return v
 	case int64, int32, int16, int8:
-		// Convert to int64 for comparison
-		return v == 0
+		i, _ := toInteger64(v)
+		return i != 0
+	case string, []byte:
+		// Postgres text output spells booleans t and f
+		b, err := strconv.ParseBool(strings.TrimSpace(fmt.Sprintf("%s", v)))
+		if err != nil {
+			m.addErr(NewColReadError(k, v, "bool"))
+			return false
+		}
+		return b
 	default:
 		m.addErr(NewColReadError(k, v, "bool"))
 		return false
@@ -580,23 +2395,165 @@ func (m *RowMap) Bool(k string) bool {
 
 // Bytes attempt to assert and return the value within
 // the RowMap with the provided key (k) as a []byte.
+// SQL NULL returns nil without recording an error.
 // If the assertion fails, then nil is returned.
 func (m *RowMap) Bytes(k string) []byte {
-	err := m.try(k)
+	val, err := m.try(k)
 
 	if err != nil {
 		m.addErr(err)
 		return nil
 	}
 
-	r, ok := m.m[k].([]byte)
+	if val == nil {
+		return nil
+	}
+
+	// a streamed column is only valid during mapping, so it is copied
+	if raw, ok := val.(sql.RawBytes); ok {
+		return bytes.Clone(raw)
+	}
+
+	r, ok := val.([]byte)
 	if !ok {
-		m.addErr(NewColReadError(k, r, "[]byte"))
+		m.addErr(NewColReadError(k, val, "[]byte"))
 		return nil
 	}
 	return r
 }
 
+// Reader returns an io.Reader over the binary value within the RowMap with the
+// provided key (k). For a column named WithStreamColumns the reader is backed
+// by the driver's buffer without a copy, it is only valid while the mapper runs
+// and must be consumed before the mapper returns. SQL NULL reads as empty.
+// If the value cannot be read, then nil is returned.
+func (m *RowMap) Reader(k string) io.Reader {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return nil
+	}
+
+	switch v := val.(type) {
+	case sql.RawBytes:
+		return bytes.NewReader(v)
+	case []byte:
+		return bytes.NewReader(v)
+	case nil:
+		return bytes.NewReader(nil)
+	}
+
+	m.addErr(NewColReadError(k, val, "io.Reader"))
+	return nil
+}
+
+// Scan passes the raw value within the RowMap with the provided
+// key (k) to the Scan method of dest, so existing sql.Scanner types
+// (enums, wrapped ids) can be used within a mapper. Any error is
+// recorded as well as returned.
+func (m *RowMap) Scan(k string, dest sql.Scanner) error {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return err
+	}
+
+	if err = dest.Scan(val); err != nil {
+		err = fmt.Errorf("cannot scan key '%s' value '%v' into %T: %w", k, val, dest, err)
+		m.addErr(err)
+		return err
+	}
+
+	return nil
+}
+
+// Time attempts to read the value within the RowMap with the provided key (k)
+// as a time.Time. Postgres, and SQLite for DATETIME columns, return a
+// time.Time, SQLite columns of other types return the text written by a bound
+// time.Time, text in the common ISO 8601 layouts is parsed.
+// The time is converted to the location set WithTimeLocation, if any.
+// SQL NULL returns the zero time without recording an error.
+// If the value cannot be read, then the zero time is returned.
+func (m *RowMap) Time(k string) time.Time {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return time.Time{}
+	}
+
+	var text string
+	switch v := val.(type) {
+	case time.Time:
+		return m.inLocation(v)
+	case nil:
+		return time.Time{}
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		m.addErr(NewColReadError(k, val, "time.Time"))
+		return time.Time{}
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return m.inLocation(t)
+		}
+	}
+
+	m.addErr(NewColReadError(k, val, "time.Time"))
+	return time.Time{}
+}
+
+// inLocation converts t to the location set WithTimeLocation, if any.
+func (m *RowMap) inLocation(t time.Time) time.Time {
+	if m.loc == nil {
+		return t
+	}
+	return t.In(m.loc)
+}
+
+// Decimal attempts to read the value within the RowMap with the
+// provided key (k) as an exact *big.Rat, for numeric/decimal columns such as
+// money where Float64 would round. Drivers usually return these columns as
+// []byte or string, integers are accepted as well.
+// SQL NULL returns nil without recording an error.
+// If the value cannot be parsed, then nil is returned.
+func (m *RowMap) Decimal(k string) *big.Rat {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return nil
+	}
+
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if r, ok := new(big.Rat).SetString(string(v)); ok {
+			return r
+		}
+	case string:
+		if r, ok := new(big.Rat).SetString(v); ok {
+			return r
+		}
+	case int64:
+		return new(big.Rat).SetInt64(v)
+	case float64:
+		if r := new(big.Rat).SetFloat64(v); r != nil {
+			return r
+		}
+	}
+
+	m.addErr(NewColReadError(k, val, "decimal"))
+	return nil
+}
+
 type ColReadError struct {
 	key    string
 	value  any
@@ -608,9 +2565,22 @@ type paramEntry struct {
 	pos  int
 	val  any
 	len  int
+	// width is the number of values in each tuple of a composite IN clause,
+	// zero when the parameter is a scalar or a flat slice
+	width int
 }
 
-var re = regexp.MustCompile(`:[a-zA-Z]+`)
+// re matches a named parameter: the sigil followed by an identifier made of
+// letters, digits and underscores, so surrounding punctuation such as "(:id,"
+// is never part of the name. A leading "::" is matched as well so Postgres
+// casts like "x::text" can be recognised and skipped.
+var re = regexp.MustCompile(`::?[a-zA-Z_][a-zA-Z0-9_]*`)
+
+// isCast reports whether a token matched by re is a Postgres cast rather than
+// a named parameter.
+func isCast(token string) bool {
+	return strings.HasPrefix(token, "::")
+}
 
 func namedParameters(s string, args map[string]any) map[string]paramEntry {
 	params := make(map[string]paramEntry)
@@ -618,21 +2588,30 @@ func namedParameters(s string, args map[string]any) map[string]paramEntry {
 	position := 0
 
 	for _, param := range matches {
+		if isCast(param) {
+			continue
+		}
 		position++
-		arg := param[1:]
+		val := lookupArg(args, param)
 		pe := paramEntry{
 			pos:  position,
-			name: param,     // original with :
-			val:  args[arg], // stripped without :
+			name: param, // original with :
+			val:  val,
 			len:  1,
 		}
 
-		switch v := args[arg].(type) {
+		switch v := val.(type) {
 		default:
 			// Check if it's any kind of slice
-			rv := reflect.ValueOf(v)
-			if rv.Kind() == reflect.Slice {
+			if rv, ok := expandable(v); ok {
 				pe.len = rv.Len()
+				// a slice of slices or structs expands to grouped placeholders
+				if rv.Len() > 0 {
+					if first, ok := tuple(rv.Index(0).Interface()); ok {
+						pe.width = len(first)
+						pe.len = rv.Len() * len(first)
+					}
+				}
 				position += rv.Len()
 			}
 		}
@@ -643,28 +2622,110 @@ func namedParameters(s string, args map[string]any) map[string]paramEntry {
 	return params
 }
 
+// lookupArg finds the value for a :name token, accepting args keyed either
+// without the sigil ("ids") or with it (":ids").
+func lookupArg(args map[string]any, param string) any {
+	if v, ok := args[param[1:]]; ok {
+		return v
+	}
+	return args[param]
+}
+
+// groupPositions gathers the placeholders of a composite IN clause into tuples
+// of width, e.g. ($1,$2), ($3,$4).
+func groupPositions(positions []string, width int) []string {
+	var groups []string
+	for chunk := range slices.Chunk(positions, width) {
+		groups = append(groups, "("+strings.Join(chunk, ",")+")")
+	}
+	return groups
+}
+
 func paramSortFunc(entry paramEntry, entry2 paramEntry) int {
 	return entry.pos - entry2.pos
 }
 
 func substitute(sql string, params map[string]paramEntry) (string, error) {
-	position := 1
+	return substituteFrom(PostgresDialect{}, sql, params, 1)
+}
+
+// substituteFrom is substitute with the placeholders of d numbered from start
+// rather than 1.
+func substituteFrom(d Dialect, sql string, params map[string]paramEntry, start int) (string, error) {
+	position := start
+	replacements := make(map[string]string, len(params))
 	// Need the entries sorted by their position so they wind up in the correct place.
 	// Could probably have written a better data structure for this as the map
 	// does have its limitations (insertion order), but that is overkill if all we
 	// need to do is this one sort.
 	for _, e := range slices.SortedFunc(maps.Values(params), paramSortFunc) {
 		if pe, exists := params[e.name]; exists {
+			// An empty or nil slice would expand to "in ( )", which is not valid SQL.
+			// Rather than guess at a predicate that is correct for both IN and NOT IN,
+			// leave it to the caller to skip the query or the condition.
+			if pe.len == 0 {
+				return "", fmt.Errorf("parameter %s is an empty slice and cannot be expanded into an IN clause", pe.name)
+			}
 			positions := make([]string, pe.len)
 			for pi := range pe.len {
-				positions[pi] = fmt.Sprintf("$%d", position)
+				positions[pi] = d.Placeholder(position)
 				position++
 			}
-			sql = strings.Replace(sql, pe.name, strings.Join(positions, ", "), -1)
+			if pe.width > 0 {
+				positions = groupPositions(positions, pe.width)
+			}
+			replacements[pe.name] = strings.Join(positions, ", ")
 		} else {
 			return "", fmt.Errorf("parameter %s not found in args %v", colorize(e.name, Red), params)
 		}
 	}
 
+	// Replace whole tokens only, a plain string replace of :id would also
+	// rewrite the start of :ids.
+	sql = re.ReplaceAllStringFunc(sql, func(token string) string {
+		if r, ok := replacements[token]; ok {
+			return r
+		}
+		return token
+	})
+
 	return sql, nil
 }
+
+// substituteOrdered is substituteFrom for a dialect such as MySQL, whose
+// placeholders bind in order of appearance and so cannot be repeated. Every
+// :name token gets placeholders of its own and the args are returned in token
+// order, binding a name used twice twice.
+func substituteOrdered(d Dialect, sql string, params map[string]paramEntry, start int) (string, []any, error) {
+	position := start
+	var args []any
+	var err error
+
+	sql = re.ReplaceAllStringFunc(sql, func(token string) string {
+		pe, ok := params[token]
+		if !ok || err != nil {
+			return token
+		}
+		if pe.len == 0 {
+			err = fmt.Errorf("parameter %s is an empty slice and cannot be expanded into an IN clause", pe.name)
+			return token
+		}
+
+		positions := make([]string, pe.len)
+		for pi := range pe.len {
+			positions[pi] = d.Placeholder(position)
+			position++
+		}
+		if pe.width > 0 {
+			positions = groupPositions(positions, pe.width)
+		}
+		args = append(args, entryArgs(pe)...)
+		return strings.Join(positions, ", ")
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sql, args, nil
+}
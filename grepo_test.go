@@ -1,15 +1,23 @@
 package grepo
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 type Album struct {
@@ -20,6 +28,7 @@ type Album struct {
 
 var (
 	albums Repository[Album]
+	testDB *sql.DB
 )
 
 func createTempDatabase(source string) (*os.File, error) {
@@ -71,6 +80,7 @@ func TestMain(m *testing.M) {
 		log.Fatal("Cannot create connection", err)
 	}
 	albums = NewRepository[Album](database)
+	testDB = database
 	code := m.Run()
 	// Cleanup
 	_ = database.Close()
@@ -130,6 +140,85 @@ func TestMapRow(t *testing.T) {
 	}
 }
 
+func TestMapRowStopsEarly(t *testing.T) {
+	mapped := 0
+
+	_, err := albums.MapRow(context.Background(), "select TrackId from Track", nil, func(r *RowMap) (*Album, error) {
+		mapped++
+		return &Album{AlbumID: r.Int64("TrackId")}, r.Err()
+	})
+
+	if !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("want ErrTooManyRows got %v", err)
+	}
+
+	if mapped != 1 {
+		t.Errorf("want the query abandoned after the second row got %d row(s) mapped", mapped)
+	}
+}
+
+func TestEmptyQuery(t *testing.T) {
+	for _, query := range []string{"", "   "} {
+		if _, err := albums.MapRows(context.Background(), query, nil, albumMapper); !errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("want ErrEmptyQuery from MapRows for %q got %v", query, err)
+		}
+
+		if _, err := albums.MapRowsN(context.Background(), query, nil, albumMapper); !errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("want ErrEmptyQuery from MapRowsN for %q got %v", query, err)
+		}
+
+		if _, err := albums.Execute(context.Background(), query, nil); !errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("want ErrEmptyQuery from Execute for %q got %v", query, err)
+		}
+	}
+}
+
+func TestPlaceholderCount(t *testing.T) {
+	query := "select AlbumId, Title, ArtistId from Album where AlbumId = $1 and ArtistId = $2"
+
+	_, err := albums.MapRows(context.Background(), query, []any{1, 1, 1}, albumMapper)
+	if err == nil || !strings.Contains(err.Error(), "2 placeholder(s) but 3 argument(s)") {
+		t.Errorf("want a placeholder count error got %v", err)
+	}
+
+	if _, err = albums.Execute(context.Background(), "delete from Artist where ArtistId = $1", nil); err == nil {
+		t.Errorf("want a placeholder count error from Execute got nil")
+	}
+
+	// a slice expands to a placeholder per element before counting
+	results, err := albums.MapRows(context.Background(),
+		"select AlbumId, Title, ArtistId from Album where AlbumId in ( $1 ) and ArtistId = $2", []any{[]int{1, 4}, 1}, albumMapper)
+	if err != nil || len(results) != 2 {
+		t.Errorf("want 2 rows with an expanded slice got %d and %v", len(results), err)
+	}
+
+	for _, c := range []struct {
+		dialect Dialect
+		query   string
+		args    int
+		ok      bool
+	}{
+		{SQLiteDialect{}, "select ? , ?", 2, true},
+		{SQLiteDialect{}, "select ?", 2, false},
+		{SQLiteDialect{}, "select $1 where $1 = $2", 2, true},
+		{SQLiteDialect{}, "select '$3', ?1", 1, true},
+		{PostgresDialect{}, "select data ? 'key' from t where id = $1", 1, true},
+		{PostgresDialect{}, "select * from t where data ? 'k'", 0, true},
+		{PostgresDialect{}, "select 1 -- why?", 0, true},
+		{SQLiteDialect{}, "select 1 -- why?", 0, true},
+		{SQLiteDialect{}, "select 1 /* why? */, ?", 1, true},
+		{PostgresDialect{}, "select $$ costs $2 $$, $body$ $3 $body$, $1", 1, true},
+		{PostgresDialect{}, "select $1, $2", 1, false},
+		{MySQLDialect{}, "select ?, `col?` from t where id = ?", 2, true},
+		{MySQLDialect{}, "select ?, ?", 1, false},
+	} {
+		err := checkPlaceholders(c.dialect, c.query, make([]any, c.args))
+		if (err == nil) != c.ok {
+			t.Errorf("want ok %t for `%s` with %d args and %T got %v", c.ok, c.query, c.args, c.dialect, err)
+		}
+	}
+}
+
 func TestExecute(t *testing.T) {
 
 	r, err := albums.Execute(
@@ -153,211 +242,1894 @@ func TestExecute(t *testing.T) {
 	}
 }
 
-func TestNamedParameters(t *testing.T) {
-	table := []struct {
-		name  string
-		query string
-		args  map[string]any
-		want  map[string]paramEntry
-	}{
-		{
-			"1",
-			"select Name from Artist where ArtistId = :artistId limit :limit",
-			map[string]any{"artistId": 1, "limit": 2},
-			map[string]paramEntry{
-				":artistId": {pos: 1, name: ":artistId", len: 1, val: 1},
-				":limit":    {pos: 2, name: ":limit", len: 1, val: 2},
-			}},
-		{
-			"2",
-			"select Name from Artist",
-			map[string]any{},
-			map[string]paramEntry{},
-		},
-		{
-			"3",
-			"select Name from Artist where ArtistId in ( :ids )",
-			map[string]any{"ids": []any{1, 2, 3}},
-			map[string]paramEntry{
-				":ids": {len: 3, pos: 1, name: ":ids", val: []any{1, 2, 3}},
-			}},
+func TestExecuteN(t *testing.T) {
+	ids, err := albums.Insert(context.Background(), "Artist", []string{"Name"},
+		[][]any{{"grepo-execute-n"}, {"grepo-execute-n"}, {"grepo-execute-n"}})
+	if err != nil {
+		t.Fatalf("failed to insert rows %v", err)
 	}
 
-	for _, a := range table {
-		t.Run(fmt.Sprintf("%s", a.name), func(t *testing.T) {
-			t.Parallel()
-			got := namedParameters(a.query, a.args)
-			if !reflect.DeepEqual(a.want, got) {
-				t.Errorf("want %+v got %+v", a.want, got)
-			}
-		})
+	r, err := albums.ExecuteN(context.Background(), "delete from Artist where ArtistId in ( :ids )", map[string]any{"ids": ids})
+	if err != nil {
+		t.Fatalf("failed to delete rows %v", err)
+	}
+
+	if r.RowsAffected != 3 {
+		t.Errorf("want 3 rows affected got %d", r.RowsAffected)
+	}
+
+	var count int64
+	if err = testDB.QueryRow(`select count(*) from Artist where Name = 'grepo-execute-n'`).Scan(&count); err != nil {
+		t.Fatalf("failed to count rows %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("want every matching row deleted got %d left", count)
 	}
 }
 
-func TestSubstitute(t *testing.T) {
-	table := []struct {
-		name  string
-		query string
-		want  string
-		m     map[string]paramEntry
-	}{
-		{
-			"one",
-			"select Name from Artist where ArtistId = :artistId limit :limit",
-			"select Name from Artist where ArtistId = $1 limit $2",
-			map[string]paramEntry{
-				":artistId": {val: 1, name: ":artistId", len: 1, pos: 1},
-				":limit":    {val: 1, name: ":limit", len: 1, pos: 2},
-			},
-		},
-		{
-			"two",
-			"select Name from Artist where ArtistId in ( :ids ) limit :limit", // yes the limit is dumb, just testing replacements
-			"select Name from Artist where ArtistId in ( $1, $2, $3 ) limit $4",
-			map[string]paramEntry{
-				":ids":   {val: []any{1, 2, 3}, name: ":ids", len: 3, pos: 1},
-				":limit": {val: 1, name: ":limit", len: 1, pos: 2},
-			},
-		},
-		{
-			"three",
-			"select Name from Artist\nwhere ArtistId in ( :ids )\nlimit :limit", // yes the limit is dumb, just testing replacements
-			"select Name from Artist\nwhere ArtistId in ( $1, $2, $3 )\nlimit $4",
-			map[string]paramEntry{
-				":ids":   {val: []any{1, 2, 3}, name: ":ids", len: 3, pos: 1},
-				":limit": {val: 1, name: ":limit", len: 1, pos: 2},
-			},
-		},
+func TestExecuteCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// generates enough rows that the insert is still running when the deadline passes
+	_, err := albums.Execute(
+		ctx,
+		`insert into Artist ("name")
+		with recursive c(x) as (select 1 union all select x + 1 from c where x < 100000000)
+		select 'grepo-cancelled' from c`,
+		nil)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want context deadline exceeded got %v", err)
 	}
 
-	// note: in test 2 there is a space between the final token and the ";". It's just the way the substitution
-	// works as all tokens are simply rewritten and spaced, otherwise the parsing becomes more complex and cumbersome.
+	var count int64
+	if err := testDB.QueryRow(`select count(*) from Artist where Name = 'grepo-cancelled'`).Scan(&count); err != nil {
+		t.Fatalf("failed to count rows %v", err)
+	}
 
-	for _, a := range table {
-		t.Run(fmt.Sprintf("%s", a.name), func(t *testing.T) {
-			t.Parallel()
-			got, err := substitute(a.query, a.m)
-			if err != nil {
-				t.Fatalf("failed substitution %v", err)
-				return
-			}
-			if a.want != got {
-				t.Errorf("want `%s` got `%s`", a.want, got)
-			}
-		})
+	if count != 0 {
+		t.Errorf("want 0 rows after rollback got %d", count)
+	}
+}
+
+func TestExecuteReadOnly(t *testing.T) {
+	db, err := openFake()
+	if err != nil {
+		t.Fatalf("failed to open fake database %v", err)
 	}
+	defer func() { _ = db.Close() }()
+
+	reports := NewRepository[Album](db, WithTxOptions(&sql.TxOptions{ReadOnly: true}))
+
+	_, err = reports.Execute(context.Background(), `insert into Artist ("name") values ($1)`, []any{"Grepo"})
 
+	if err == nil {
+		t.Errorf("want error writing in a read-only transaction got nil")
+	}
 }
 
-func TestRepository_MapRowN(t *testing.T) {
+func TestExecuteWithoutLastInsertId(t *testing.T) {
+	db, err := openFake()
+	if err != nil {
+		t.Fatalf("failed to open fake database %v", err)
+	}
+	defer func() { _ = db.Close() }()
 
-	table := []struct {
-		query string
-		args  map[string]any
-		count int
-	}{
-		{
-			query: "select AlbumID, Title, ArtistID from Album where AlbumId = :id",
-			args: map[string]any{
-				"id": 1,
-			},
-			count: 1,
-		},
+	// the fake is treated as Postgres, which has no LastInsertId
+	artists := NewRepository[Album](db)
+
+	r, err := artists.Execute(context.Background(), `insert into Artist ("name") values ($1)`, []any{"Grepo"})
+	if err != nil {
+		t.Fatalf("want no error without LastInsertId got %v", err)
 	}
 
-	for _, a := range table {
-		results, err := albums.MapRowN(
-			context.Background(),
-			a.query,
-			a.args,
-			func(r *RowMap) (*Album, error) {
-				return &Album{
-					AlbumID:  r.Int64("AlbumId"),
-					Title:    r.String("Title"),
-					ArtistID: r.Int32("ArtistId"),
-				}, r.Err()
-			})
-		if err != nil {
-			t.Errorf("%v", err)
-			return
-		}
-		if results == nil {
-			t.Error(fmt.Errorf("expected results"))
-		}
+	if r.RowsAffected != 1 || r.LastInsertId != 0 {
+		t.Errorf("want 1 row affected and id 0 got %+v", r)
 	}
 }
 
-func TestRepository_MapRowNFails(t *testing.T) {
+func TestWithTx(t *testing.T) {
+	rollback := errors.New("rollback")
 
-	_, err := albums.MapRowN(
-		context.Background(),
-		"select AlbumID, Title, ArtistID from Album where AlbumId = :id",
-		map[string]any{
-			"id": 1,
-		},
-		func(r *RowMap) (*Album, error) {
-			return &Album{
-				AlbumID:  r.Int64("AlbumID"), //
-				Title:    r.String("Title"),
-				ArtistID: r.Int32("ArtistID"),
-			}, r.Err()
-		})
+	err := albums.WithTx(context.Background(), func(tx Repository[Album]) error {
+		if _, err := tx.Execute(context.Background(), `insert into Artist ("name") values ($1)`, []any{"grepo-tx"}); err != nil {
+			return err
+		}
+		return rollback
+	})
 
-	if err == nil {
-		t.Errorf("want error got nil")
-		return
+	if !errors.Is(err, rollback) {
+		t.Fatalf("want rollback error got %v", err)
+	}
+
+	var count int64
+	if err := testDB.QueryRow(`select count(*) from Artist where Name = 'grepo-tx'`).Scan(&count); err != nil {
+		t.Fatalf("failed to count rows %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("want 0 rows after rollback got %d", count)
 	}
 }
 
-func TestRepository_MapRowsN(t *testing.T) {
-	table := []struct {
-		query string
-		args  map[string]any
-		count int
-	}{
-		{
-			query: "select AlbumID, Title, ArtistID from Album where AlbumId < :maxId",
-			args: map[string]any{
-				"maxId": 4,
-			},
-			count: 3,
-		},
-		{
+func TestAtomic(t *testing.T) {
+	ctx := context.Background()
 
-			query: "select ArtistId from Artist where ArtistId in (:artistIds)",
-			args: map[string]any{
-				"artistIds": []any{1, 2, 3},
-			},
-			count: 3,
-		},
+	if _, err := testDB.Exec(`create table GrepoCounter (id integer primary key, n integer)`); err != nil {
+		t.Fatalf("failed to create table %v", err)
 	}
+	defer func() { _, _ = testDB.Exec(`drop table GrepoCounter`) }()
 
-	for _, a := range table {
-		results, err := albums.MapRowsN(
-			context.Background(),
-			a.query,
-			a.args,
-			func(r *RowMap) (*Album, error) {
-				return &Album{
-					AlbumID:  r.Int64("AlbumId"),
-					Title:    r.String("Title"),
-					ArtistID: r.Int32("ArtistId"),
-				}, nil
-			})
+	if _, err := testDB.Exec(`insert into GrepoCounter (id, n) values (1, 41)`); err != nil {
+		t.Fatalf("failed to insert row %v", err)
+	}
 
-		if err != nil {
-			t.Error(fmt.Errorf("want 2 results, got zero from retrieving rows %w", err))
-			return
+	increment := func(tx Repository[Album]) (int64, error) {
+		var n int64
+		if err := tx.ScanValues(ctx, `select n from GrepoCounter where id = 1`, nil, &n); err != nil {
+			return 0, err
 		}
+		if _, err := tx.Execute(ctx, `update GrepoCounter set n = $1 where id = 1`, []any{n + 1}); err != nil {
+			return 0, err
+		}
+		return n + 1, nil
+	}
 
-		if results == nil {
-			t.Error(fmt.Errorf("want 3 results, got nil"))
-			return
+	n, err := Atomic(ctx, albums, increment)
+	if err != nil || n != 42 {
+		t.Fatalf("want 42 got %d and %v", n, err)
+	}
+
+	rollback := errors.New("rollback")
+	n, err = Atomic(ctx, albums, func(tx Repository[Album]) (int64, error) {
+		if _, err := increment(tx); err != nil {
+			return 0, err
 		}
+		return 0, rollback
+	})
 
-		if len(results) != 3 {
-			t.Error(fmt.Errorf("want 3 results, got %d", len(results)))
-			return
+	if !errors.Is(err, rollback) || n != 0 {
+		t.Errorf("want the rollback error and 0 got %d and %v", n, err)
+	}
+
+	var stored int64
+	if err = testDB.QueryRow(`select n from GrepoCounter where id = 1`).Scan(&stored); err != nil || stored != 42 {
+		t.Errorf("want 42 stored after the rollback got %d and %v", stored, err)
+	}
+}
+
+func TestDebugLogging(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	traced := NewRepository[Album](testDB, WithLogger(logger))
+
+	_, err := traced.MapRowsN(context.Background(),
+		"select AlbumId, Title, ArtistId from Album where AlbumId in ( :ids )", map[string]any{"ids": []int{1, 2}}, albumMapper)
+	if err != nil {
+		t.Fatalf("failed to query %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	var queries []string
+	for _, line := range lines {
+		if strings.Contains(line, `msg="executing query"`) {
+			queries = append(queries, line)
 		}
 	}
+
+	if len(queries) != 1 {
+		t.Fatalf("want 1 query logged got %d in %s", len(queries), logs.String())
+	}
+
+	if !strings.Contains(queries[0], "AlbumId in ( ?1, ?2 )") || !strings.Contains(queries[0], "args=2") {
+		t.Errorf("want the rewritten SQL and arg count logged got %s", queries[0])
+	}
+
+	if !strings.Contains(queries[0], "<int>") {
+		t.Errorf("want the arg values redacted got %s", queries[0])
+	}
+
+	logs.Reset()
+	quiet := NewRepository[Album](testDB, WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	if _, err = quiet.MapRows(context.Background(), "select AlbumId from Album where AlbumId = $1", []any{1}, albumIdMapper); err != nil {
+		t.Fatalf("failed to query %v", err)
+	}
+
+	if logs.Len() != 0 {
+		t.Errorf("want nothing logged above debug got %s", logs.String())
+	}
+}
+
+func TestStats(t *testing.T) {
+	db, err := openFake()
+	if err != nil {
+		t.Fatalf("failed to open fake database %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	db.SetMaxOpenConns(4)
+	stats := NewRepository[Album](db).Stats()
+
+	if stats.MaxOpenConnections != 4 {
+		t.Errorf("want 4 max open connections got %d", stats.MaxOpenConnections)
+	}
+
+	if got := (repository[Album]{}).Stats(); got != (sql.DBStats{}) {
+		t.Errorf("want zero stats without a database got %+v", got)
+	}
+}
+
+func TestStreamColumns(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := testDB.Exec(`create table GrepoBlob (id integer primary key, data blob)`); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+	defer func() { _, _ = testDB.Exec(`drop table GrepoBlob`) }()
+
+	blob := bytes.Repeat([]byte("grepo-blob"), 8*1024)
+	if _, err := testDB.Exec(`insert into GrepoBlob (data) values ($1)`, blob); err != nil {
+		t.Fatalf("failed to insert blob %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "blob")
+	blobs := NewRepository[int64](testDB, WithStreamColumns("data"))
+
+	_, err := blobs.MapRows(ctx, `select id, data from GrepoBlob`, nil, func(r *RowMap) (*int64, error) {
+		f, err := os.Create(out)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+
+		n, err := io.Copy(f, r.Reader("data"))
+		if err != nil {
+			return nil, err
+		}
+		return &n, r.Err()
+	})
+
+	if err != nil {
+		t.Fatalf("failed to stream blob %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read streamed blob %v", err)
+	}
+
+	if !bytes.Equal(got, blob) {
+		t.Errorf("want %d bytes streamed got %d", len(blob), len(got))
+	}
+}
+
+func TestQuery(t *testing.T) {
+	rows, err := albums.Query(context.Background(), "select AlbumId, Title, ArtistId from Album where AlbumId < $1", []any{3})
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("want 2 rows got %d", len(rows))
+	}
+
+	for _, r := range rows {
+		for _, k := range []string{"AlbumId", "Title", "ArtistId"} {
+			if _, ok := r.m[k]; !ok {
+				t.Errorf("want key %s in %v", k, r.m)
+			}
+		}
+	}
+
+	if got := rows[1].String("Title"); got != "Balls to the Wall" {
+		t.Errorf("want Balls to the Wall got %s", got)
+	}
+
+	named, err := albums.QueryN(context.Background(), "select AlbumId from Album where ArtistId = :artist", map[string]any{"artist": 1})
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(named) != 2 {
+		t.Errorf("want 2 rows got %d", len(named))
+	}
+}
+
+func TestQueryStreamColumnsByIndex(t *testing.T) {
+	streamed := NewRepository[Album](testDB, WithStreamColumns("Title"))
+	rows, err := streamed.Query(context.Background(), "select AlbumId, Title from Album where AlbumId < $1 order by AlbumId", []any{3})
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("want 2 rows got %d and %v", len(rows), err)
+	}
+
+	// the driver reuses the buffer of a streamed column for the next row
+	for i, want := range []string{"For Those About To Rock We Salute You", "Balls to the Wall"} {
+		v, _ := rows[i].ByIndex(1)
+		if got, ok := v.([]byte); !ok || string(got) != want {
+			t.Errorf("want %s at index 1 of row %d got %v", want, i, v)
+		}
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	_, err := albums.MapRowsN(
+		context.Background(),
+		"select AlbumId, Title from Album wher Title = :title",
+		map[string]any{"title": "private"},
+		albumMapper)
+
+	var queryErr QueryError
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("want a QueryError got %v", err)
+	}
+
+	if want := "select AlbumId, Title from Album wher Title = ?1"; queryErr.SQL() != want {
+		t.Errorf("want sql `%s` got `%s`", want, queryErr.SQL())
+	}
+
+	if !reflect.DeepEqual(queryErr.Args(), []string{"<string>"}) || strings.Contains(err.Error(), "private") {
+		t.Errorf("want the args redacted got %v", queryErr.Args())
+	}
+
+	unredacted := NewRepository[Album](testDB, WithUnredactedArgs())
+	_, err = unredacted.Execute(context.Background(), `insert into NoSuchTable ("name") values ($1)`, []any{"grepo"})
+
+	if !errors.As(err, &queryErr) || !reflect.DeepEqual(queryErr.Args(), []string{"grepo"}) {
+		t.Errorf("want the unredacted args got %v", err)
+	}
+}
+
+func TestTimeArgs(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := testDB.Exec(`create table GrepoTime (id integer primary key, at text)`); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+	defer func() { _, _ = testDB.Exec(`drop table GrepoTime`) }()
+
+	at := time.Date(2024, time.March, 9, 14, 30, 15, 250000000, time.FixedZone("EST", -5*60*60))
+
+	if _, err := albums.Execute(ctx, `insert into GrepoTime (at) values ($1)`, []any{at}); err != nil {
+		t.Fatalf("failed to insert time %v", err)
+	}
+
+	rows, err := albums.QueryN(ctx, `select at, date(at) as day from GrepoTime where at = :at`, map[string]any{"at": at})
+	if err != nil {
+		t.Fatalf("failed to read time %v", err)
+	}
+
+	if len(rows) != 1 {
+		t.Fatalf("want 1 row got %d", len(rows))
+	}
+
+	if got := rows[0].Time("at"); !got.Equal(at) {
+		t.Errorf("want %v got %v", at, got)
+	}
+
+	if got := rows[0].String("day"); got != "2024-03-09" {
+		t.Errorf("want the stored text understood by date() got %s", got)
+	}
+
+	if err = rows[0].Err(); err != nil {
+		t.Errorf("want no errors got %v", err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	rows, err := albums.Query(context.Background(), "select AlbumId, Title, ArtistId from Album where AlbumId = $1", []any{1})
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if got := rows[0].Keys(); !reflect.DeepEqual(got, []string{"AlbumId", "ArtistId", "Title"}) {
+		t.Errorf("want [AlbumId ArtistId Title] got %v", got)
+	}
+}
+
+func TestToMap(t *testing.T) {
+	r := toMap([]string{"name", "text", "blob", "id"}, []any{"Grepo", []byte("text"), []byte{0xff, 0xfe}, int64(1)})
+
+	m := r.ToMap()
+	want := map[string]any{"name": "Grepo", "text": "text", "blob": []byte{0xff, 0xfe}, "id": int64(1)}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("want %v got %v", want, m)
+	}
+
+	m["id"] = int64(2)
+	m["blob"].([]byte)[0] = 0
+	delete(m, "name")
+
+	if got := r.Int64("id"); got != 1 {
+		t.Errorf("want the original id 1 got %d", got)
+	}
+
+	if got := r.Bytes("blob"); got[0] != 0xff {
+		t.Errorf("want the original blob got %v", got)
+	}
+
+	if got := r.String("name"); got != "Grepo" || r.Err() != nil {
+		t.Errorf("want the original name got %s and %v", got, r.Err())
+	}
+}
+
+func TestRowMapMarshalJSON(t *testing.T) {
+	rows, err := albums.Query(
+		context.Background(),
+		"select TrackId, Name, Composer, UnitPrice from Track where TrackId = $1",
+		[]any{63})
+
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	got, err := json.Marshal(rows)
+	if err != nil {
+		t.Fatalf("failed to marshal rows %v", err)
+	}
+
+	want := `[{"TrackId":63,"Name":"Desafinado","Composer":null,"UnitPrice":0.99}]`
+	if string(got) != want {
+		t.Errorf("want %s got %s", want, got)
+	}
+
+	r := toMap([]string{"text"}, []any{[]byte("Grepo")})
+	if got, _ := json.Marshal(r); string(got) != `{"text":"Grepo"}` {
+		t.Errorf("want the bytes as a string got %s", got)
+	}
+}
+
+func TestTimeLocation(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := testDB.Exec(`create table GrepoTimeLocation (id integer primary key, at datetime, text text)`); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+	defer func() { _, _ = testDB.Exec(`drop table GrepoTimeLocation`) }()
+
+	at := time.Date(2024, time.March, 9, 9, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	if _, err := albums.Execute(ctx, `insert into GrepoTimeLocation (at, text) values ($1, $1)`, []any{at}); err != nil {
+		t.Fatalf("failed to insert time %v", err)
+	}
+
+	utc := NewRepository[Album](testDB, WithTimeLocation(time.UTC))
+	rows, err := utc.Query(ctx, `select at, text from GrepoTimeLocation`, nil)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("failed to read time %v", err)
+	}
+
+	for _, k := range []string{"at", "text"} {
+		got := rows[0].Time(k)
+		if got.Location() != time.UTC || !got.Equal(at) || got.Hour() != 14 {
+			t.Errorf("want %v in UTC from %s got %v", at.UTC(), k, got)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	at := time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC)
+	r := toMap([]string{"time", "text", "null", "invalid"}, []any{at, []byte("2024-03-09"), nil, "soon"})
+
+	if got := r.Time("time"); !got.Equal(at) {
+		t.Errorf("want %v got %v", at, got)
+	}
+
+	if got := r.Time("text"); !got.Equal(at) {
+		t.Errorf("want %v got %v", at, got)
+	}
+
+	if got := r.Time("null"); !got.IsZero() || r.Err() != nil {
+		t.Errorf("want the zero time without error got %v and %v", got, r.Err())
+	}
+
+	if got := r.Time("invalid"); !got.IsZero() || r.Err() == nil {
+		t.Errorf("want the zero time and an error got %v and %v", got, r.Err())
+	}
+}
+
+func TestScanValues(t *testing.T) {
+	var lo, hi, count int64
+
+	err := albums.ScanValues(context.Background(), `select min(AlbumId), max(AlbumId), count(*) from Album`, nil, &lo, &hi, &count)
+	if err != nil {
+		t.Fatalf("failed to scan values %v", err)
+	}
+
+	if lo != 1 || hi != 347 || count != 347 {
+		t.Errorf("want 1, 347, 347 got %d, %d, %d", lo, hi, count)
+	}
+
+	var countErr ScanCountError
+	err = albums.ScanValues(context.Background(), `select min(AlbumId), max(AlbumId) from Album`, nil, &lo, &hi, &count)
+	if !errors.As(err, &countErr) {
+		t.Fatalf("want a ScanCountError for a destination count mismatch got %v", err)
+	}
+
+	if countErr.Destinations() != 3 || len(countErr.Columns()) != 2 {
+		t.Errorf("want 3 destinations for 2 columns got %d for %v", countErr.Destinations(), countErr.Columns())
+	}
+
+	if !strings.Contains(err.Error(), "3 scan destination(s) for 2 column(s)") {
+		t.Errorf("want both counts named in the error got %v", err)
+	}
+
+	if err = albums.ScanValues(context.Background(), `select AlbumId from Album where AlbumId < 3`, nil, &lo); err == nil {
+		t.Errorf("want error for more than one row got nil")
+	}
+
+	if err = albums.ScanValues(context.Background(), `select AlbumId from Album where AlbumId < 0`, nil, &lo); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("want sql.ErrNoRows got %v", err)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	type albumStats struct {
+		Count int64
+		MaxID int64
+	}
+
+	stats, err := NewRepository[albumStats](testDB).Aggregate(context.Background(), `select count(*), max(AlbumId) from Album`, nil)
+	if err != nil {
+		t.Fatalf("failed to aggregate %v", err)
+	}
+
+	if stats.Count != 347 || stats.MaxID != 347 {
+		t.Errorf("want 347 and 347 got %+v", stats)
+	}
+
+	if _, err = NewRepository[albumStats](testDB).Aggregate(context.Background(), `select count(*) from Album`, nil); err == nil {
+		t.Errorf("want error for a field count mismatch got nil")
+	}
+}
+
+func TestExecuteNoTx(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := albums.ExecuteNoTx(ctx, `create table GrepoNoTx (id integer primary key, name text)`, nil); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+	defer func() { _, _ = albums.ExecuteNoTx(ctx, `drop table GrepoNoTx`, nil) }()
+
+	r, err := albums.ExecuteNoTx(ctx, `insert into GrepoNoTx (name) values ($1)`, []any{"grepo"})
+	if err != nil {
+		t.Fatalf("failed to insert row %v", err)
+	}
+
+	if r.RowsAffected != 1 {
+		t.Errorf("want 1 row affected got %d", r.RowsAffected)
+	}
+}
+
+func TestExecuteScript(t *testing.T) {
+	results, err := albums.ExecuteScript(context.Background(), []string{
+		`insert into Artist ("name") values ('grepo-script')`,
+		`insert into NoSuchTable ("name") values ('grepo-script')`,
+	})
+
+	if err == nil {
+		t.Fatalf("want error from the second statement got nil")
+	}
+
+	if results != nil {
+		t.Errorf("want no results after rollback got %v", results)
+	}
+
+	var count int64
+	if err := testDB.QueryRow(`select count(*) from Artist where Name = 'grepo-script'`).Scan(&count); err != nil {
+		t.Fatalf("failed to count rows %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("want 0 rows after rollback got %d", count)
+	}
+
+	results, err = albums.ExecuteScript(context.Background(), []string{
+		`insert into Artist ("name") values ('grepo-script')`,
+		`update Artist set Name = 'grepo-script' where Name = 'grepo-script'`,
+	})
+
+	if err != nil {
+		t.Fatalf("failed to run script %v", err)
+	}
+
+	if len(results) != 2 || results[0].RowsAffected != 1 || results[1].RowsAffected != 1 {
+		t.Errorf("want 1 row affected by each statement got %v", results)
+	}
+}
+
+func TestNamedParameters(t *testing.T) {
+	table := []struct {
+		name  string
+		query string
+		args  map[string]any
+		want  map[string]paramEntry
+	}{
+		{
+			"1",
+			"select Name from Artist where ArtistId = :artistId limit :limit",
+			map[string]any{"artistId": 1, "limit": 2},
+			map[string]paramEntry{
+				":artistId": {pos: 1, name: ":artistId", len: 1, val: 1},
+				":limit":    {pos: 2, name: ":limit", len: 1, val: 2},
+			}},
+		{
+			"2",
+			"select Name from Artist",
+			map[string]any{},
+			map[string]paramEntry{},
+		},
+		{
+			"3",
+			"select Name from Artist where ArtistId in ( :ids )",
+			map[string]any{"ids": []any{1, 2, 3}},
+			map[string]paramEntry{
+				":ids": {len: 3, pos: 1, name: ":ids", val: []any{1, 2, 3}},
+			}},
+		{
+			"prefixed keys",
+			"select Name from Artist where ArtistId = :artistId limit :limit",
+			map[string]any{":artistId": 1, "limit": 2},
+			map[string]paramEntry{
+				":artistId": {pos: 1, name: ":artistId", len: 1, val: 1},
+				":limit":    {pos: 2, name: ":limit", len: 1, val: 2},
+			}},
+	}
+
+	for _, a := range table {
+		t.Run(fmt.Sprintf("%s", a.name), func(t *testing.T) {
+			t.Parallel()
+			got := namedParameters(a.query, a.args)
+			if !reflect.DeepEqual(a.want, got) {
+				t.Errorf("want %+v got %+v", a.want, got)
+			}
+		})
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	table := []struct {
+		name  string
+		query string
+		want  string
+		m     map[string]paramEntry
+	}{
+		{
+			"one",
+			"select Name from Artist where ArtistId = :artistId limit :limit",
+			"select Name from Artist where ArtistId = $1 limit $2",
+			map[string]paramEntry{
+				":artistId": {val: 1, name: ":artistId", len: 1, pos: 1},
+				":limit":    {val: 1, name: ":limit", len: 1, pos: 2},
+			},
+		},
+		{
+			"two",
+			"select Name from Artist where ArtistId in ( :ids ) limit :limit", // yes the limit is dumb, just testing replacements
+			"select Name from Artist where ArtistId in ( $1, $2, $3 ) limit $4",
+			map[string]paramEntry{
+				":ids":   {val: []any{1, 2, 3}, name: ":ids", len: 3, pos: 1},
+				":limit": {val: 1, name: ":limit", len: 1, pos: 2},
+			},
+		},
+		{
+			"tuples",
+			"select Title from Album where (ArtistId, AlbumId) in ( :pairs )",
+			"select Title from Album where (ArtistId, AlbumId) in ( ($1,$2), ($3,$4) )",
+			map[string]paramEntry{
+				":pairs": {val: [][]any{{1, 1}, {1, 4}}, name: ":pairs", len: 4, pos: 1, width: 2},
+			},
+		},
+		{
+			"three",
+			"select Name from Artist\nwhere ArtistId in ( :ids )\nlimit :limit", // yes the limit is dumb, just testing replacements
+			"select Name from Artist\nwhere ArtistId in ( $1, $2, $3 )\nlimit $4",
+			map[string]paramEntry{
+				":ids":   {val: []any{1, 2, 3}, name: ":ids", len: 3, pos: 1},
+				":limit": {val: 1, name: ":limit", len: 1, pos: 2},
+			},
+		},
+	}
+
+	// note: in test 2 there is a space between the final token and the ";". It's just the way the substitution
+	// works as all tokens are simply rewritten and spaced, otherwise the parsing becomes more complex and cumbersome.
+
+	for _, a := range table {
+		t.Run(fmt.Sprintf("%s", a.name), func(t *testing.T) {
+			t.Parallel()
+			got, err := substitute(a.query, a.m)
+			if err != nil {
+				t.Fatalf("failed substitution %v", err)
+				return
+			}
+			if a.want != got {
+				t.Errorf("want `%s` got `%s`", a.want, got)
+			}
+		})
+	}
+
+}
+
+func TestSubstituteFrom(t *testing.T) {
+	params := map[string]paramEntry{
+		":ids":  {val: []any{1, 2}, name: ":ids", len: 2, pos: 0},
+		":name": {val: "Grepo", name: ":name", len: 1, pos: 1},
+	}
+
+	got, err := substituteFrom(PostgresDialect{}, "AlbumId in ( :ids ) and Title = :name", params, 3)
+	if err != nil {
+		t.Fatalf("failed substitution %v", err)
+	}
+
+	if want := "AlbumId in ( $3, $4 ) and Title = $5"; want != got {
+		t.Errorf("want `%s` got `%s`", want, got)
+	}
+}
+
+func TestBindNamedFrom(t *testing.T) {
+	fragment, fragmentArgs, err := BindNamed("Title like :title", map[string]any{"title": "F%"}, 2)
+	if err != nil {
+		t.Fatalf("failed to bind fragment %v", err)
+	}
+
+	if want := "Title like $2"; want != fragment {
+		t.Errorf("want `%s` got `%s`", want, fragment)
+	}
+
+	results, err := albums.MapRows(
+		context.Background(),
+		"select AlbumId, Title, ArtistId from Album where ArtistId = $1 and "+fragment,
+		append([]any{1}, fragmentArgs...),
+		albumMapper)
+
+	if err != nil {
+		t.Fatalf("failed to map rows %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Errorf("want 1 album got %d", len(results))
+	}
+
+	if _, _, err = BindNamed("Title = :title", map[string]any{"title": "x"}, 0); err == nil {
+		t.Errorf("want error for a start of 0 got nil")
+	}
+}
+
+func TestRepository_MapRowN(t *testing.T) {
+
+	table := []struct {
+		query string
+		args  map[string]any
+		count int
+	}{
+		{
+			query: "select AlbumID, Title, ArtistID from Album where AlbumId = :id",
+			args: map[string]any{
+				"id": 1,
+			},
+			count: 1,
+		},
+	}
+
+	for _, a := range table {
+		results, err := albums.MapRowN(
+			context.Background(),
+			a.query,
+			a.args,
+			func(r *RowMap) (*Album, error) {
+				return &Album{
+					AlbumID:  r.Int64("AlbumId"),
+					Title:    r.String("Title"),
+					ArtistID: r.Int32("ArtistId"),
+				}, r.Err()
+			})
+		if err != nil {
+			t.Errorf("%v", err)
+			return
+		}
+		if results == nil {
+			t.Error(fmt.Errorf("expected results"))
+		}
+	}
+}
+
+func TestRepository_MapRowNFails(t *testing.T) {
+
+	_, err := albums.MapRowN(
+		context.Background(),
+		"select AlbumID, Title, ArtistID from Album where AlbumId = :id",
+		map[string]any{
+			"id": 1,
+		},
+		func(r *RowMap) (*Album, error) {
+			return &Album{
+				AlbumID:  r.Int64("AlbumID"), //
+				Title:    r.String("Title"),
+				ArtistID: r.Int32("ArtistID"),
+			}, r.Err()
+		})
+
+	if err == nil {
+		t.Errorf("want error got nil")
+		return
+	}
+}
+
+func TestRepository_MapRowsN(t *testing.T) {
+	table := []struct {
+		query string
+		args  map[string]any
+		count int
+	}{
+		{
+			query: "select AlbumID, Title, ArtistID from Album where AlbumId < :maxId",
+			args: map[string]any{
+				"maxId": 4,
+			},
+			count: 3,
+		},
+		{
+
+			query: "select ArtistId from Artist where ArtistId in (:artistIds)",
+			args: map[string]any{
+				"artistIds": []any{1, 2, 3},
+			},
+			count: 3,
+		},
+	}
+
+	for _, a := range table {
+		results, err := albums.MapRowsN(
+			context.Background(),
+			a.query,
+			a.args,
+			func(r *RowMap) (*Album, error) {
+				return &Album{
+					AlbumID:  r.Int64("AlbumId"),
+					Title:    r.String("Title"),
+					ArtistID: r.Int32("ArtistId"),
+				}, nil
+			})
+
+		if err != nil {
+			t.Error(fmt.Errorf("want 2 results, got zero from retrieving rows %w", err))
+			return
+		}
+
+		if results == nil {
+			t.Error(fmt.Errorf("want 3 results, got nil"))
+			return
+		}
+
+		if len(results) != 3 {
+			t.Error(fmt.Errorf("want 3 results, got %d", len(results)))
+			return
+		}
+	}
+}
+
+func TestCheckArgCount(t *testing.T) {
+	// the tuples are sized by the first, so the ragged second leaves a
+	// placeholder without an argument
+	_, _, err := bindNamedWith(PostgresDialect{}, "select * from Track where (AlbumId, MediaTypeId) in ( :pairs ) limit :limit",
+		map[string]any{"pairs": [][]any{{1, 1}, {2}}, "limit": 1}, 1)
+
+	if err == nil {
+		t.Fatalf("want placeholder mismatch error got nil")
+	}
+
+	want := "parameter :pairs generated 4 placeholder(s) but supplied 3 argument(s)"
+	if err.Error() != want {
+		t.Errorf("want `%s` got `%s`", want, err.Error())
+	}
+}
+
+func TestBindNamedEmptySlice(t *testing.T) {
+	table := []struct {
+		name string
+		ids  any
+	}{
+		{"empty", []any{}},
+		{"nil", []int(nil)},
+	}
+
+	for _, a := range table {
+		t.Run(a.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := bindNamed(
+				"select Name from Artist where ArtistId in ( :ids )",
+				map[string]any{"ids": a.ids})
+
+			if err == nil {
+				t.Errorf("want error for %s slice got nil", a.name)
+			}
+		})
+	}
+}
+
+// slowQuery counts far enough that it is still running when any test timeout passes.
+const slowQuery = `with recursive c(x) as (select 1 union all select x + 1 from c where x < 1000000000)
+select count(*) as AlbumId from c`
+
+func TestDefaultQueryTimeout(t *testing.T) {
+	repo := NewRepository[Album](testDB, WithDefaultQueryTimeout(50*time.Millisecond))
+
+	_, err := repo.MapRows(context.Background(), slowQuery, nil, func(r *RowMap) (*Album, error) {
+		return &Album{AlbumID: r.Int64("AlbumId")}, r.Err()
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want context deadline exceeded got %v", err)
+	}
+}
+
+func TestDefaultQueryTimeoutKeepsDeadline(t *testing.T) {
+	repo := NewRepository[Album](testDB, WithDefaultQueryTimeout(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := repo.MapRows(ctx, slowQuery, nil, func(r *RowMap) (*Album, error) {
+		return &Album{AlbumID: r.Int64("AlbumId")}, r.Err()
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want context deadline exceeded got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("want the caller's deadline to apply, query ran for %v", elapsed)
+	}
+}
+
+func TestMapRowsValues(t *testing.T) {
+	results, err := albums.MapRowsValues(
+		context.Background(),
+		"select AlbumId, Title, ArtistId from Album where AlbumId < $1",
+		[]any{4},
+		func(r *RowMap) (*Album, error) {
+			return &Album{
+				AlbumID:  r.Int64("AlbumId"),
+				Title:    r.String("Title"),
+				ArtistID: r.Int32("ArtistId"),
+			}, r.Err()
+		})
+
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Errorf("want 3 results got %d", len(results))
+	}
+}
+
+func albumMapper(r *RowMap) (*Album, error) {
+	return &Album{
+		AlbumID:  r.Int64("AlbumId"),
+		Title:    r.String("Title"),
+		ArtistID: r.Int32("ArtistId"),
+	}, r.Err()
+}
+
+func BenchmarkMapRows(b *testing.B) {
+	for b.Loop() {
+		if _, err := albums.MapRows(context.Background(), "select AlbumId, Title, ArtistId from Album", nil, albumMapper); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMapRowsValues(b *testing.B) {
+	for b.Loop() {
+		if _, err := albums.MapRowsValues(context.Background(), "select AlbumId, Title, ArtistId from Album", nil, albumMapper); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestExpandPositional(t *testing.T) {
+	table := []struct {
+		name  string
+		query string
+		args  []any
+		want  string
+		count int
+	}{
+		{
+			"scalars only",
+			"select Name from Artist where ArtistId = $1",
+			[]any{1},
+			"select Name from Artist where ArtistId = $1",
+			1,
+		},
+		{
+			"slice then scalar",
+			"select Name from Artist where ArtistId in ( $1 ) and Name <> $2",
+			[]any{[]int{1, 2, 3}, "x"},
+			"select Name from Artist where ArtistId in ( $1, $2, $3 ) and Name <> $4",
+			4,
+		},
+		{
+			"bytes are not expanded",
+			"select Name from Artist where ArtistId in ( $2 ) and Name <> $1",
+			[]any{[]byte("x"), []int{1, 2}},
+			"select Name from Artist where ArtistId in ( $2, $3 ) and Name <> $1",
+			3,
+		},
+	}
+
+	for _, a := range table {
+		t.Run(a.name, func(t *testing.T) {
+			t.Parallel()
+			got, args, err := expandPositional(PostgresDialect{}, a.query, a.args)
+			if err != nil {
+				t.Fatalf("failed expansion %v", err)
+			}
+			if a.want != got {
+				t.Errorf("want `%s` got `%s`", a.want, got)
+			}
+			if len(args) != a.count {
+				t.Errorf("want %d args got %d", a.count, len(args))
+			}
+		})
+	}
+}
+
+func TestExpandPositionalDialects(t *testing.T) {
+	table := []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{SQLiteDialect{}, "select 1 where a in ( ?1 ) and b = ?2", "select 1 where a in ( ?1, ?2 ) and b = ?3"},
+		{SQLiteDialect{}, "select 1 where a in ( ? ) and b = ?", "select 1 where a in ( ?, ? ) and b = ?"},
+		{SQLiteDialect{}, "select 1 where a in ( $1 ) and b = $2 -- $1", "select 1 where a in ( $1, $2 ) and b = $3 -- $1"},
+		{MySQLDialect{}, "select 1 where a in ( ? ) and b = '?' and c = ?", "select 1 where a in ( ?, ? ) and b = '?' and c = ?"},
+		{PostgresDialect{}, "select 1 where a in ( $1 ) and d ? 'k' and b = $2", "select 1 where a in ( $1, $2 ) and d ? 'k' and b = $3"},
+	}
+
+	for _, a := range table {
+		got, args, err := expandPositional(a.dialect, a.query, []any{[]int{1, 2}, "x"})
+		if err != nil {
+			t.Fatalf("failed expansion %v", err)
+		}
+		if got != a.want || len(args) != 3 || args[2] != "x" {
+			t.Errorf("want `%s` with 3 args for %T got `%s` with %v", a.want, a.dialect, got, args)
+		}
+	}
+
+	results, err := albums.MapRows(context.Background(),
+		"select AlbumId, Title, ArtistId from Album where AlbumId in ( ?1 ) and ArtistId = ?2", []any{[]int{1, 2, 4}, 1}, albumMapper)
+	if err != nil || len(results) != 2 {
+		t.Errorf("want 2 albums with a slice bound to ?1 got %d and %v", len(results), err)
+	}
+}
+
+func TestMapRowsApply(t *testing.T) {
+	upper := func(a *Album, r *RowMap) (*Album, error) {
+		a.Title = strings.ToUpper(a.Title)
+		return a, nil
+	}
+
+	results, err := albums.MapRowsApply(
+		context.Background(),
+		"select AlbumId, Title, ArtistId from Album where AlbumId < $1",
+		[]any{3},
+		albumMapper,
+		upper)
+
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(results) != 2 || results[1].Title != "BALLS TO THE WALL" {
+		t.Errorf("want the derived upper case titles got %v", results)
+	}
+
+	invalid := errors.New("album by artist 2 is not allowed")
+	validate := func(a *Album, r *RowMap) (*Album, error) {
+		if a.ArtistID == 2 {
+			return nil, invalid
+		}
+		return a, nil
+	}
+
+	_, err = albums.MapRowsApply(
+		context.Background(),
+		"select AlbumId, Title, ArtistId from Album where AlbumId < $1",
+		[]any{3},
+		albumMapper,
+		upper,
+		validate)
+
+	if !errors.Is(err, invalid) {
+		t.Errorf("want the validation error got %v", err)
+	}
+}
+
+func TestMapRowsPositionalSlice(t *testing.T) {
+	results, err := albums.MapRows(
+		context.Background(),
+		"select ArtistId from Artist where ArtistId in ( $1 ) and ArtistId < $2",
+		[]any{[]int{1, 2, 3, 4}, 4},
+		func(r *RowMap) (*Album, error) {
+			return &Album{ArtistID: r.Int32("ArtistId")}, r.Err()
+		})
+
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Errorf("want 3 results got %d", len(results))
+	}
+}
+
+func TestStrictColumns(t *testing.T) {
+	strict := NewRepository[Album](testDB, WithStrictColumns())
+
+	_, err := strict.MapRows(
+		context.Background(),
+		"select AlbumId, Title, ArtistId from Album",
+		nil,
+		func(r *RowMap) (*Album, error) {
+			// the mapper ignores r.Err(), strict mode must still catch the typo
+			return &Album{
+				AlbumID:  r.Int64("AlbumId"),
+				Title:    r.String("Tilte"),
+				ArtistID: r.Int32("ArtistId"),
+			}, nil
+		})
+
+	if err == nil {
+		t.Fatalf("want error for misspelled column got nil")
+	}
+
+	want := "mapper requested column(s) [Tilte] which are not in the result, available columns are [AlbumId Title ArtistId]"
+	if err.Error() != want {
+		t.Errorf("want `%s` got `%s`", want, err.Error())
+	}
+}
+
+func TestFailOnRowMapError(t *testing.T) {
+	// the mapper reads the Title as an int and never returns r.Err()
+	careless := func(r *RowMap) (*Album, error) {
+		return &Album{AlbumID: r.Int64("Title")}, nil
+	}
+
+	if _, err := albums.MapRows(context.Background(), "select Title from Album where AlbumId = 1", nil, careless); err != nil {
+		t.Fatalf("want the error dropped by default got %v", err)
+	}
+
+	strict := NewRepository[Album](testDB, WithFailOnRowMapError())
+	_, err := strict.MapRows(context.Background(), "select Title from Album where AlbumId = 1", nil, careless)
+
+	var colErr ColReadError
+	if !errors.As(err, &colErr) {
+		t.Errorf("want a ColReadError got %v", err)
+	}
+}
+
+func TestContinueOnError(t *testing.T) {
+	errThird := errors.New("every third album")
+	everyThird := func(r *RowMap) (*Album, error) {
+		a, err := albumMapper(r)
+		if err == nil && a.AlbumID%3 == 0 {
+			return nil, errThird
+		}
+		return a, err
+	}
+
+	query := "select AlbumId, Title, ArtistId from Album where AlbumId <= 9 order by AlbumId"
+
+	if _, err := albums.MapRows(context.Background(), query, nil, everyThird); !errors.Is(err, errThird) {
+		t.Fatalf("want the first mapper error by default got %v", err)
+	}
+
+	lenient := NewRepository[Album](testDB, WithContinueOnError())
+	results, err := lenient.MapRows(context.Background(), query, nil, everyThird)
+
+	if !errors.Is(err, errThird) {
+		t.Fatalf("want the mapper errors joined got %v", err)
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 3 {
+		t.Errorf("want an error for each of rows 3, 6 and 9 got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "row 6:") {
+		t.Errorf("want the row number in the error got %v", err)
+	}
+
+	if len(results) != 6 || results[2].AlbumID != 4 {
+		t.Errorf("want the 6 albums which mapped got %d", len(results))
+	}
+}
+
+func TestCaseInsensitiveColumns(t *testing.T) {
+	folding := NewRepository[Album](testDB, WithCaseInsensitiveColumns())
+
+	var lower, upper int64
+	_, err := folding.MapRow(
+		context.Background(),
+		"select ArtistId from Artist where ArtistId = $1",
+		[]any{1},
+		func(r *RowMap) (*Album, error) {
+			lower = r.Int64("artistid")
+			upper = r.Int64("ArtistID")
+			return &Album{}, r.Err()
+		})
+
+	if err != nil {
+		t.Fatalf("want both keys to resolve got %v", err)
+	}
+
+	if lower != 1 || upper != 1 {
+		t.Errorf("want 1 and 1 got %d and %d", lower, upper)
+	}
+}
+
+func TestLowercaseColumns(t *testing.T) {
+	lowered := NewRepository[Album](testDB, WithLowercaseColumns())
+
+	mapper := func(r *RowMap) (*Album, error) {
+		return &Album{
+			AlbumID:  r.Int64("albumid"),
+			Title:    r.String("title"),
+			ArtistID: r.Int32("artistid"),
+		}, r.Err()
+	}
+
+	// the second query returns the names the way Postgres folds them
+	for _, query := range []string{
+		"select AlbumId, Title, ArtistId from Album where AlbumId = $1",
+		"select AlbumId as albumid, Title as title, ArtistId as artistid from Album where AlbumId = $1",
+	} {
+		album, err := lowered.MapRow(context.Background(), query, []any{2}, mapper)
+		if err != nil {
+			t.Fatalf("failed to map `%s` %v", query, err)
+		}
+
+		if album.AlbumID != 2 || album.Title != "Balls to the Wall" || album.ArtistID != 2 {
+			t.Errorf("want album 2 from `%s` got %+v", query, album)
+		}
+	}
+}
+
+func TestSimple(t *testing.T) {
+	mapper := Simple(func(r *RowMap) *Album {
+		return &Album{
+			AlbumID:  r.Int64("AlbumId"),
+			Title:    r.String("Title"),
+			ArtistID: r.Int32("ArtistId"),
+		}
+	})
+
+	album, err := albums.MapRow(
+		context.Background(),
+		"select AlbumId, Title, ArtistId from Album where AlbumId = $1",
+		[]any{1},
+		mapper)
+
+	if err != nil {
+		t.Fatalf("error retrieving row %v", err)
+	}
+
+	if album == nil || album.AlbumID != 1 {
+		t.Errorf("want album with AlbumId 1 got %+v", album)
+	}
+
+	_, err = albums.MapRow(
+		context.Background(),
+		"select AlbumId, Title from Album where AlbumId = $1",
+		[]any{1},
+		mapper)
+
+	if err == nil {
+		t.Errorf("want error for the missing ArtistId column got nil")
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	var amount, invalid *big.Rat
+	var mapErr error
+
+	_, err := albums.MapRow(
+		context.Background(),
+		"select '12345678901234567890.123456789' as Amount, 'abc' as Invalid",
+		nil,
+		func(r *RowMap) (*Album, error) {
+			amount = r.Decimal("Amount")
+			invalid = r.Decimal("Invalid")
+			mapErr = r.Err()
+			return &Album{}, nil
+		})
+
+	if err != nil {
+		t.Fatalf("failed to map row %v", err)
+	}
+
+	if amount == nil || amount.FloatString(9) != "12345678901234567890.123456789" {
+		t.Errorf("want 12345678901234567890.123456789 got %v", amount)
+	}
+
+	var colErr ColReadError
+	if invalid != nil || !errors.As(mapErr, &colErr) {
+		t.Errorf("want nil and a ColReadError for an invalid decimal got %v and %v", invalid, mapErr)
+	}
+}
+
+func TestIntegerOverflow(t *testing.T) {
+	r := toMap([]string{"big"}, []any{int64(300)})
+
+	if got := r.Int8("big"); got != 0 {
+		t.Errorf("want 0 for an overflowing value got %d", got)
+	}
+
+	var colErr ColReadError
+	if !errors.As(r.Err(), &colErr) {
+		t.Fatalf("want a ColReadError for 300 read as int8 got %v", r.Err())
+	}
+
+	r = toMap([]string{"small"}, []any{int64(-128)})
+	if got := r.Int8("small"); got != -128 || r.Err() != nil {
+		t.Errorf("want -128 without error got %d and %v", got, r.Err())
+	}
+}
+
+func TestIntegerTrunc(t *testing.T) {
+	r := toMap([]string{"big"}, []any{int64(1<<32 + 7)})
+
+	if got := r.Int32Trunc("big"); got != 7 || r.Err() != nil {
+		t.Errorf("want 7 truncated without error got %d and %v", got, r.Err())
+	}
+
+	if got := r.Int32("big"); got != 0 || r.Err() == nil {
+		t.Errorf("want 0 and an error from Int32 got %d and %v", got, r.Err())
+	}
+
+	r = toMap([]string{"big"}, []any{int64(300)})
+	if got := r.Int8Trunc("big"); got != 44 {
+		t.Errorf("want 44 got %d", got)
+	}
+
+	if got := r.Int16Trunc("big"); got != 300 || r.Err() != nil {
+		t.Errorf("want 300 without error got %d and %v", got, r.Err())
+	}
+}
+
+// MediaKind is an enum stored as an integer which implements sql.Scanner.
+type MediaKind int
+
+const (
+	MediaUnknown MediaKind = iota
+	MediaAudio
+	MediaVideo
+)
+
+func (k *MediaKind) Scan(src any) error {
+	v, ok := src.(int64)
+	if !ok || v < int64(MediaUnknown) || v > int64(MediaVideo) {
+		return fmt.Errorf("invalid media kind %v", src)
+	}
+	*k = MediaKind(v)
+	return nil
+}
+
+func TestRowMapScan(t *testing.T) {
+	r := toMap([]string{"kind", "invalid"}, []any{int64(2), int64(9)})
+
+	var kind MediaKind
+	if err := r.Scan("kind", &kind); err != nil {
+		t.Fatalf("failed to scan %v", err)
+	}
+
+	if kind != MediaVideo {
+		t.Errorf("want MediaVideo got %d", kind)
+	}
+
+	var invalid MediaKind
+	if err := r.Scan("invalid", &invalid); err == nil {
+		t.Errorf("want error scanning an invalid media kind got nil")
+	}
+
+	if r.Err() == nil {
+		t.Errorf("want the scan error recorded on the RowMap")
+	}
+}
+
+func TestDuplicateColumns(t *testing.T) {
+	_, err := albums.MapRows(
+		context.Background(),
+		"select a.Name, b.Name from Artist a join Artist b on a.ArtistId = b.ArtistId limit 1",
+		nil,
+		func(r *RowMap) (*Album, error) {
+			return &Album{Title: r.String("Name")}, r.Err()
+		})
+
+	if err == nil {
+		t.Fatalf("want error for duplicate column names got nil")
+	}
+
+	want := "query returned duplicate column name(s) [Name], alias the columns so each name is unique"
+	if err.Error() != want {
+		t.Errorf("want `%s` got `%s`", want, err.Error())
+	}
+}
+
+func TestMapRowsInto(t *testing.T) {
+	dest := make([]*Album, 0, 8)
+	dest = append(dest, &Album{})
+
+	err := albums.MapRowsInto(
+		context.Background(),
+		"select AlbumId, Title, ArtistId from Album where AlbumId < $1",
+		[]any{4},
+		albumMapper,
+		&dest)
+
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(dest) != 3 || dest[0].AlbumID != 1 {
+		t.Errorf("want the 3 albums in place of the previous contents got %d", len(dest))
+	}
+}
+
+func BenchmarkMapRowsFresh(b *testing.B) {
+	for b.Loop() {
+		if _, err := albums.MapRows(context.Background(), "select AlbumId, Title, ArtistId from Album where AlbumId < $1", []any{50}, albumMapper); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMapRowsDirect and BenchmarkMapRowsPreparePerCall compare sending a
+// one-off query directly, as MapRows does, with explicitly preparing and
+// closing a statement for every call.
+func BenchmarkMapRowsDirect(b *testing.B) {
+	for b.Loop() {
+		if _, err := albums.MapRows(context.Background(), "select AlbumId, Title, ArtistId from Album where AlbumId = $1", []any{1}, albumMapper); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMapRowsPreparePerCall(b *testing.B) {
+	for b.Loop() {
+		q := albums.Prepare("select AlbumId, Title, ArtistId from Album where AlbumId = :id", albumMapper)
+		if _, err := q.Many(context.Background(), map[string]any{"id": 1}); err != nil {
+			b.Fatal(err)
+		}
+		if err := q.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMapRowsInto(b *testing.B) {
+	dest := make([]*Album, 0, 64)
+	for b.Loop() {
+		if err := albums.MapRowsInto(context.Background(), "select AlbumId, Title, ArtistId from Album where AlbumId < $1", []any{50}, albumMapper, &dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMixedPlaceholders(t *testing.T) {
+	table := []struct {
+		name  string
+		query string
+		err   bool
+	}{
+		{"named only", "select * from Album where AlbumId = :id", false},
+		{"question mark", "select * from Album where AlbumId = :id and ArtistId = ?", true},
+		{"numbered question mark", "select * from Album where AlbumId = :id and ArtistId = ?2", true},
+		{"dollar", "select * from Album where AlbumId = :id and ArtistId = $2", true},
+		{"inside a literal", "select * from Album where AlbumId = :id and Title <> 'why? $1'", false},
+		{"inside a quoted identifier", `select AlbumId as "id?" from Album where AlbumId = :id`, false},
+	}
+
+	for _, a := range table {
+		t.Run(a.name, func(t *testing.T) {
+			_, _, err := bindNamed(a.query, map[string]any{"id": 1})
+			if a.err && err == nil {
+				t.Errorf("want error for a mix of placeholders got nil")
+			}
+			if !a.err && err != nil {
+				t.Errorf("want no error got %v", err)
+			}
+		})
+	}
+
+	// a query with no named parameters is positional and left alone
+	if _, _, err := bindNamed("select * from Album where AlbumId = ?", nil); err != nil {
+		t.Errorf("want no error for a positional only query got %v", err)
+	}
+}
+
+func TestStrictParams(t *testing.T) {
+	args := map[string]any{"id": 1, ":title": "unused", "artist": 1}
+	query := "select AlbumId, Title, ArtistId from Album where AlbumId = :id and ArtistId = :artist"
+
+	if _, err := albums.MapRowsN(context.Background(), query, args, albumMapper); err != nil {
+		t.Fatalf("want unused parameters ignored by default got %v", err)
+	}
+
+	strict := NewRepository[Album](testDB, WithStrictParams())
+	_, err := strict.MapRowsN(context.Background(), query, args, albumMapper)
+
+	if err == nil || !strings.Contains(err.Error(), "[:title]") {
+		t.Errorf("want an error listing :title got %v", err)
+	}
+
+	delete(args, ":title")
+	if _, err = strict.MapRowsN(context.Background(), query, args, albumMapper); err != nil {
+		t.Errorf("want no error when every parameter is used got %v", err)
+	}
+	// a cast is not a parameter
+	if got := unusedParams("select :name::text", map[string]any{"name": 1, "text": 2}); !reflect.DeepEqual(got, []string{"text"}) {
+		t.Errorf("want [text] unused got %v", got)
+	}
+}
+
+func TestMapRowsNPrefixedKeys(t *testing.T) {
+	for _, key := range []string{"ids", ":ids"} {
+		results, err := albums.MapRowsN(
+			context.Background(),
+			"select ArtistId from Artist where ArtistId in ( :ids )",
+			map[string]any{key: []any{1, 2, 3}},
+			func(r *RowMap) (*Album, error) {
+				return &Album{ArtistID: r.Int32("ArtistId")}, r.Err()
+			})
+
+		if err != nil {
+			t.Fatalf("error retrieving rows with key %s %v", key, err)
+		}
+
+		if len(results) != 3 {
+			t.Errorf("want 3 results with key %s got %d", key, len(results))
+		}
+	}
+}
+
+func TestBindNamedTokens(t *testing.T) {
+	table := []struct {
+		name  string
+		query string
+		args  map[string]any
+		want  string
+		count int
+	}{
+		{"trailing comma", "select :id, Name from Artist", map[string]any{"id": 1}, "select $1, Name from Artist", 1},
+		{"closing paren", "select Name from Artist where ArtistId in (:id)", map[string]any{"id": 1}, "select Name from Artist where ArtistId in ($1)", 1},
+		{"opening paren", "select Name from Artist where (:id = ArtistId)", map[string]any{"id": 1}, "select Name from Artist where ($1 = ArtistId)", 1},
+		{"underscores and digits", "select :artist_id, :id2", map[string]any{"artist_id": 1, "id2": 2}, "select $1, $2", 2},
+		{"prefix of another name", "select :ids, :id", map[string]any{"id": 1, "ids": []int{2, 3}}, "select $1, $2, $3", 3},
+		{"postgres cast", "select :id::text", map[string]any{"id": 1}, "select $1::text", 1},
+	}
+
+	for _, a := range table {
+		t.Run(a.name, func(t *testing.T) {
+			t.Parallel()
+			got, args, err := bindNamed(a.query, a.args)
+			if err != nil {
+				t.Fatalf("failed to bind %v", err)
+			}
+			if a.want != got {
+				t.Errorf("want `%s` got `%s`", a.want, got)
+			}
+			if len(args) != a.count {
+				t.Errorf("want %d args got %d", a.count, len(args))
+			}
+		})
+	}
+}
+
+func TestColMissingError(t *testing.T) {
+	r := toMap([]string{"Title"}, []any{"Balls to the Wall"})
+
+	r.Int64("AlbumId")
+
+	var missing ColMissingError
+	if !errors.As(r.Err(), &missing) {
+		t.Fatalf("want a ColMissingError got %v", r.Err())
+	}
+
+	if missing.Key() != "AlbumId" || !reflect.DeepEqual(missing.Columns(), []string{"Title"}) {
+		t.Errorf("want key AlbumId and columns [Title] got %s and %v", missing.Key(), missing.Columns())
+	}
+
+	r = toMap([]string{"Title"}, []any{"Balls to the Wall"})
+
+	r.Int64("Title")
+
+	var colErr ColReadError
+	if !errors.As(r.Err(), &colErr) || errors.As(r.Err(), &missing) {
+		t.Errorf("want only a ColReadError for a bad cast got %v", r.Err())
+	}
+}
+
+type Status int8
+
+func TestInt(t *testing.T) {
+	var id, count int
+
+	_, err := albums.MapRow(context.Background(),
+		"select AlbumId, (select count(*) from Album) as Albums from Album where AlbumId = $1", []any{2},
+		func(r *RowMap) (*Album, error) {
+			id = r.Int("AlbumId")
+			count = r.Int("Albums")
+			return &Album{}, r.Err()
+		})
+
+	if err != nil || id != 2 || count != 347 {
+		t.Errorf("want album 2 of 347 got %d of %d and %v", id, count, err)
+	}
+}
+
+func TestByIndex(t *testing.T) {
+	var count int64
+	var found bool
+
+	_, err := albums.MapRow(context.Background(), "select count(*) from Album", nil,
+		func(r *RowMap) (*Album, error) {
+			_, found = r.ByIndex(0)
+			count = r.Int64At(0)
+			return &Album{}, r.Err()
+		})
+
+	if err != nil || !found || count != 347 {
+		t.Errorf("want 347 albums at index 0 got %d, %t and %v", count, found, err)
+	}
+
+	r := toMap([]string{"AlbumId", "Title"}, []any{int64(2), "Balls to the Wall"})
+
+	if v, ok := r.ByIndex(2); ok || v != nil || r.Err() != nil {
+		t.Errorf("want no value or error for index 2 got %v, %t and %v", v, ok, r.Err())
+	}
+
+	if got := r.StringAt(1); got != "Balls to the Wall" || r.Err() != nil {
+		t.Errorf("want Balls to the Wall got %s and %v", got, r.Err())
+	}
+
+	if got := r.Int64At(5); got != 0 || r.Err() == nil {
+		t.Errorf("want an error for index 5 got %d", got)
+	}
+
+	r.ResetErrors()
+	var colErr ColReadError
+	if got := r.Float64At(1); got != 0 || !errors.As(r.Err(), &colErr) {
+		t.Errorf("want a ColReadError reading a title as float64 got %v and %v", got, r.Err())
+	}
+}
+
+func TestUint(t *testing.T) {
+	r := toMap([]string{"Big", "Small", "Negative", "Wide"}, []any{uint64(1 << 63), int64(200), int64(-1), int64(300)})
+
+	if got := r.Uint64("Big"); got != 1<<63 || r.Err() != nil {
+		t.Errorf("want %d got %d and %v", uint64(1<<63), got, r.Err())
+	}
+
+	if got, n := r.Uint8("Small"), r.Uint("Small"); got != 200 || n != 200 || r.Err() != nil {
+		t.Errorf("want 200 got %d, %d and %v", got, n, r.Err())
+	}
+
+	var colErr ColReadError
+	if got := r.Uint32("Negative"); got != 0 || !errors.As(r.Err(), &colErr) {
+		t.Errorf("want 0 and a ColReadError for a negative source got %d and %v", got, r.Err())
+	}
+
+	r.ResetErrors()
+	if got := r.Uint8("Wide"); got != 0 || !errors.As(r.Err(), &colErr) {
+		t.Errorf("want 0 and a ColReadError for a value overflowing uint8 got %d and %v", got, r.Err())
+	}
+
+	r.ResetErrors()
+	if got := r.Uint16("Wide"); got != 300 || r.Err() != nil {
+		t.Errorf("want 300 got %d and %v", got, r.Err())
+	}
+}
+
+func TestInteger(t *testing.T) {
+	r := toMap([]string{"Status", "Big"}, []any{int64(3), int64(300)})
+
+	var status Status = Integer[Status](r, "Status")
+	if status != 3 || r.Err() != nil {
+		t.Errorf("want status 3 got %d and %v", status, r.Err())
+	}
+
+	var colErr ColReadError
+	if Integer[Status](r, "Big"); !errors.As(r.Err(), &colErr) {
+		t.Errorf("want a ColReadError for a value overflowing Status got %v", r.Err())
+	}
+}
+
+func TestRaw(t *testing.T) {
+	r := toMap([]string{"Total", "Composer"}, []any{1.98, nil})
+
+	if v, ok := r.Raw("Total"); !ok || v != 1.98 {
+		t.Errorf("want 1.98 got %v and %t", v, ok)
+	}
+
+	if v, ok := r.Raw("Composer"); !ok || v != nil {
+		t.Errorf("want a present NULL got %v and %t", v, ok)
+	}
+
+	if v, ok := r.Raw("Missing"); ok || v != nil {
+		t.Errorf("want a missing key got %v and %t", v, ok)
+	}
+
+	if err := r.Err(); err != nil {
+		t.Errorf("want no errors from Raw got %v", err)
+	}
+}
+
+func TestResetErrors(t *testing.T) {
+	r := toMap([]string{"Title"}, []any{"Balls to the Wall"})
+
+	if r.String("Name"); r.Err() == nil {
+		t.Fatalf("want error probing a missing column got nil")
+	}
+
+	r.ResetErrors()
+
+	if title := r.String("Title"); title != "Balls to the Wall" || r.Err() != nil {
+		t.Errorf("want Balls to the Wall and no error got %s and %v", title, r.Err())
+	}
+}
+
+func TestStringNull(t *testing.T) {
+	query := "select Composer, '' as Empty, TrackId from Track where TrackId = $1"
+
+	rows, err := albums.Query(context.Background(), query, []any{63})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("want 1 row got %d and %v", len(rows), err)
+	}
+	r := rows[0]
+
+	if got := r.String("Composer"); got != "" || r.Err() != nil {
+		t.Errorf("want \"\" without error for NULL got %q and %v", got, r.Err())
+	}
+
+	if got := r.String("Empty"); got != "" || r.Err() != nil {
+		t.Errorf("want \"\" without error for an empty string got %q and %v", got, r.Err())
+	}
+
+	var colErr ColReadError
+	if r.String("TrackId"); !errors.As(r.Err(), &colErr) {
+		t.Errorf("want a ColReadError for an integer column got %v", r.Err())
+	}
+
+	strict := NewRepository[Album](testDB, WithNullStringError())
+	rows, err = strict.Query(context.Background(), query, []any{63})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("want 1 row got %d and %v", len(rows), err)
+	}
+
+	if rows[0].String("Composer"); !errors.As(rows[0].Err(), &colErr) {
+		t.Errorf("want a ColReadError for NULL WithNullStringError got %v", rows[0].Err())
+	}
+}
+
+func TestMaxRows(t *testing.T) {
+	capped := NewRepository[Album](testDB, WithMaxRows(2))
+
+	_, err := capped.MapRows(context.Background(), "select AlbumId, Title, ArtistId from Album", nil, albumMapper)
+
+	if !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("want ErrTooManyRows got %v", err)
+	}
+
+	results, err := capped.MapRows(context.Background(), "select AlbumId, Title, ArtistId from Album where AlbumId < $1", []any{3}, albumMapper)
+
+	if err != nil || len(results) != 2 {
+		t.Errorf("want 2 rows within the cap got %d and %v", len(results), err)
+	}
+}
+
+func TestMapRowsNTuples(t *testing.T) {
+	type key struct {
+		ArtistID int
+		AlbumID  int
+	}
+
+	table := []struct {
+		name  string
+		pairs any
+	}{
+		{"slices", [][]any{{1, 1}, {1, 4}, {2, 2}}},
+		{"structs", []key{{1, 1}, {1, 4}, {2, 2}}},
+	}
+
+	for _, a := range table {
+		results, err := albums.MapRowsN(
+			context.Background(),
+			"select AlbumId, Title, ArtistId from Album where (ArtistId, AlbumId) in ( :pairs )",
+			map[string]any{"pairs": a.pairs},
+			albumMapper)
+
+		if err != nil {
+			t.Fatalf("error retrieving rows for %s %v", a.name, err)
+		}
+
+		if len(results) != 3 {
+			t.Errorf("want 3 results for %s got %d", a.name, len(results))
+		}
+	}
+}
+
+func TestNullScalars(t *testing.T) {
+	r := toMap([]string{"Composer"}, []any{nil})
+
+	if got := r.Int64("Composer"); got != 0 {
+		t.Errorf("want 0 for NULL got %d", got)
+	}
+
+	if got := r.Float64("Composer"); got != 0 {
+		t.Errorf("want 0 for NULL got %f", got)
+	}
+
+	if got := r.Bool("Composer"); got {
+		t.Errorf("want false for NULL got %t", got)
+	}
+
+	if got := r.Decimal("Composer"); got != nil {
+		t.Errorf("want nil for NULL got %v", got)
+	}
+
+	if got := r.IntSlice("Composer"); got != nil {
+		t.Errorf("want nil for NULL got %v", got)
+	}
+
+	if got := r.StringSlice("Composer"); got != nil {
+		t.Errorf("want nil for NULL got %v", got)
+	}
+
+	if err := r.Err(); err != nil {
+		t.Errorf("want no errors for NULL got %v", err)
+	}
+}
+
+func TestBytesNull(t *testing.T) {
+	r := toMap([]string{"data", "id"}, []any{nil, int64(7)})
+
+	if got := r.Bytes("data"); got != nil || r.Err() != nil {
+		t.Errorf("want nil without error for a NULL blob got %v and %v", got, r.Err())
+	}
+
+	var colErr ColReadError
+	if got := r.Bytes("id"); got != nil || !errors.As(r.Err(), &colErr) {
+		t.Errorf("want nil and a ColReadError for an integer column got %v and %v", got, r.Err())
+	}
+}
+
+func TestBool(t *testing.T) {
+	values := []any{"t", "f", "true", "false", []byte("t"), []byte("f"), true, int64(1), int64(0)}
+	want := []bool{true, false, true, false, true, false, true, true, false}
+
+	cols := make([]string, len(values))
+	for i := range cols {
+		cols[i] = fmt.Sprintf("c%d", i)
+	}
+	r := toMap(cols, values)
+
+	for i, col := range cols {
+		if got := r.Bool(col); got != want[i] {
+			t.Errorf("want %t for %#v got %t", want[i], values[i], got)
+		}
+	}
+
+	if err := r.Err(); err != nil {
+		t.Errorf("want no errors got %v", err)
+	}
+
+	r = toMap([]string{"Name"}, []any{"AC/DC"})
+	var colErr ColReadError
+	if r.Bool("Name"); !errors.As(r.Err(), &colErr) {
+		t.Errorf("want a ColReadError for a string which is not a boolean got %v", r.Err())
+	}
 }
@@ -0,0 +1,142 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultHealthInterval is the time between pings when NewHealthMonitor is
+// given no interval.
+const defaultHealthInterval = 30 * time.Second
+
+// pingTimeout bounds each ping of a health check.
+const pingTimeout = 5 * time.Second
+
+// Health is the outcome of the latest check made by a HealthMonitor.
+type Health struct {
+	// Healthy is true when the database answered the latest ping
+	Healthy bool
+	// Err is why the latest check failed, nil when healthy, with the password
+	// of any connection string it repeats masked
+	Err error
+	// CheckedAt is when the latest check finished, zero before the first one
+	CheckedAt time.Time
+}
+
+// HealthMonitor pings the database of a connector in the background and keeps
+// the outcome, for readiness and liveness endpoints which should not wait on
+// the database themselves.
+type HealthMonitor struct {
+	connector Connector
+	interval  time.Duration
+	// db is the handle being pinged, only used by the monitoring goroutine
+	db     *sql.DB
+	mu     sync.Mutex
+	health Health
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewHealthMonitor starts pinging the database of connector straight away and
+// then every interval, 30 seconds when interval is not positive, until ctx is
+// cancelled or Close is called. The monitor only pings and records the
+// outcome. It keeps pinging the handle the connector gave it, database/sql
+// redials the broken connections of its pool, and asks the connector again only
+// once the handle has been closed, when a PostgresConnector replaces it. The
+// monitor never closes a handle, they are shared with the repositories.
+func NewHealthMonitor(ctx context.Context, connector Connector, interval time.Duration) *HealthMonitor {
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	h := &HealthMonitor{
+		connector: connector,
+		interval:  interval,
+		health:    Health{Err: errors.New("the database has not been checked yet")},
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	go h.run(ctx)
+
+	return h
+}
+
+// Health returns the outcome of the latest check. Until the first check
+// finishes it is unhealthy.
+func (h *HealthMonitor) Health() Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.health
+}
+
+// Close stops the monitor, waiting for a check in progress to finish. The
+// connector is left open, it is closed by whoever created it.
+func (h *HealthMonitor) Close() error {
+	h.cancel()
+	<-h.done
+	return nil
+}
+
+func (h *HealthMonitor) run(ctx context.Context) {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check pings the handle and records the outcome.
+func (h *HealthMonitor) check(ctx context.Context) {
+	if h.db != nil && isClosed(h.db) {
+		h.db = nil
+	}
+
+	err := h.ping(ctx)
+
+	if ctx.Err() != nil {
+		// stopped while checking, the failure says nothing about the database
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.health = Health{
+		Healthy:   err == nil,
+		Err:       redactError(err, ""),
+		CheckedAt: time.Now(),
+	}
+}
+
+// ping pings the handle, getting one from the connector when there is none,
+// which is until the connector first succeeds and after the handle was closed.
+func (h *HealthMonitor) ping(ctx context.Context) error {
+	if h.db == nil {
+		db, err := h.connector.GetConnection()
+		if err != nil {
+			return err
+		}
+		h.db = db
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	return h.db.PingContext(ctx)
+}
@@ -0,0 +1,124 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// waitForHealth polls h until its health is want or a second has passed.
+func waitForHealth(t *testing.T, h *HealthMonitor, want bool) Health {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		health := h.Health()
+		if health.Healthy == want && !health.CheckedAt.IsZero() {
+			return health
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("want healthy %t got %+v", want, health)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealthMonitor(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database %v", err)
+	}
+
+	h := NewHealthMonitor(context.Background(), NewConnectorFromDB(db), 10*time.Millisecond)
+	defer func() { _ = h.Close() }()
+
+	if health := waitForHealth(t, h, true); health.Err != nil {
+		t.Errorf("want no error while healthy got %v", health.Err)
+	}
+
+	_ = db.Close()
+
+	if health := waitForHealth(t, h, false); health.Err == nil {
+		t.Errorf("want the ping error once the database is closed")
+	}
+}
+
+func TestHealthMonitorReconnects(t *testing.T) {
+	c := NewPostgresConnector(Database{Host: "localhost", Provider: "grepo-fake"})
+	defer func() { _ = c.Close() }()
+
+	h := NewHealthMonitor(context.Background(), c, 10*time.Millisecond)
+	defer func() { _ = h.Close() }()
+
+	waitForHealth(t, h, true)
+
+	db, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("failed to connect %v", err)
+	}
+	_ = db.Close()
+
+	// healthy again once the connector has replaced the closed handle
+	deadline := time.Now().Add(time.Second)
+	for {
+		if again, _ := c.GetConnection(); again != db && h.Health().Healthy {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("want the closed handle replaced got %+v", h.Health())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealthMonitorOutage(t *testing.T) {
+	c := NewPostgresConnector(Database{Host: "flaky", Provider: "grepo-fake"})
+	defer func() { _ = c.Close() }()
+
+	db, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("failed to connect %v", err)
+	}
+
+	h := NewHealthMonitor(context.Background(), c, 5*time.Millisecond)
+	defer func() { _ = h.Close() }()
+
+	waitForHealth(t, h, true)
+
+	fakeOutage.Store(true)
+	health := waitForHealth(t, h, false)
+	fakeOutage.Store(false)
+
+	if health.Err == nil {
+		t.Errorf("want the ping error during the outage")
+	}
+
+	waitForHealth(t, h, true)
+
+	// the handle shared with repositories is left open
+	if err = db.Ping(); err != nil {
+		t.Errorf("want the connector's handle open after the outage got %v", err)
+	}
+}
+
+func TestHealthMonitorStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewHealthMonitor(ctx, NewConnectorFromDB(testDB), 5*time.Millisecond)
+
+	waitForHealth(t, h, true)
+	cancel()
+
+	// give a check in progress time to finish, then there are no more
+	time.Sleep(20 * time.Millisecond)
+	checked := h.Health().CheckedAt
+	time.Sleep(50 * time.Millisecond)
+
+	if got := h.Health().CheckedAt; !got.Equal(checked) {
+		t.Errorf("want no checks after the context is cancelled got one at %v", got)
+	}
+
+	if err := h.Close(); err != nil {
+		t.Errorf("want Close after cancel to succeed got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package grepo
+
+import "fmt"
+
+// Nullable holds a column value which may be SQL NULL. Valid is false when the
+// column was NULL or the value could not be read as T.
+type Nullable[T any] struct {
+	Value T
+	Valid bool
+}
+
+// Get reads the value within the RowMap with the provided key (k) as a
+// Nullable[T]. A NULL column yields a Nullable that is not Valid without
+// recording an error. Integer, unsigned, float, string, bool and []byte
+// targets are read through the matching RowMap accessor, any other type is
+// asserted directly.
+func Get[T any](m *RowMap, k string) Nullable[T] {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return Nullable[T]{}
+	}
+
+	if val == nil {
+		return Nullable[T]{}
+	}
+
+	var zero T
+	var v any
+	errs := len(m.errors)
+
+	switch any(zero).(type) {
+	case int64:
+		v = m.Int64(k)
+	case int32:
+		v = m.Int32(k)
+	case int16:
+		v = m.Int16(k)
+	case int8:
+		v = m.Int8(k)
+	case int:
+		v = m.Int(k)
+	case uint64:
+		v = m.Uint64(k)
+	case uint32:
+		v = m.Uint32(k)
+	case uint16:
+		v = m.Uint16(k)
+	case uint8:
+		v = m.Uint8(k)
+	case uint:
+		v = m.Uint(k)
+	case float64:
+		v = m.Float64(k)
+	case float32:
+		v = m.Float32(k)
+	case string:
+		v = m.String(k)
+	case bool:
+		v = m.Bool(k)
+	case []byte:
+		v = m.Bytes(k)
+	default:
+		t, ok := val.(T)
+		if !ok {
+			m.addErr(NewColReadError(k, val, fmt.Sprintf("%T", zero)))
+			return Nullable[T]{}
+		}
+		return Nullable[T]{Value: t, Valid: true}
+	}
+
+	if len(m.errors) > errs {
+		return Nullable[T]{}
+	}
+
+	return Nullable[T]{Value: v.(T), Valid: true}
+}
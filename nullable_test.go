@@ -0,0 +1,65 @@
+package grepo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	var (
+		nullInt Nullable[int64]
+		someInt Nullable[int64]
+		nullStr Nullable[string]
+		someStr Nullable[string]
+		mapErr  error
+	)
+
+	_, err := albums.MapRow(
+		context.Background(),
+		"select null as NullInt, 42 as SomeInt, null as NullStr, 'grepo' as SomeStr",
+		nil,
+		func(r *RowMap) (*Album, error) {
+			nullInt = Get[int64](r, "NullInt")
+			someInt = Get[int64](r, "SomeInt")
+			nullStr = Get[string](r, "NullStr")
+			someStr = Get[string](r, "SomeStr")
+			mapErr = r.Err()
+			return &Album{}, nil
+		})
+
+	if err != nil {
+		t.Fatalf("failed to map row %v", err)
+	}
+
+	if mapErr != nil {
+		t.Errorf("want no errors for NULL columns got %v", mapErr)
+	}
+
+	if nullInt.Valid || nullStr.Valid {
+		t.Errorf("want NULL columns to be invalid got %+v %+v", nullInt, nullStr)
+	}
+
+	if !someInt.Valid || someInt.Value != 42 {
+		t.Errorf("want valid 42 got %+v", someInt)
+	}
+
+	if !someStr.Valid || someStr.Value != "grepo" {
+		t.Errorf("want valid 'grepo' got %+v", someStr)
+	}
+}
+
+func TestGetIntAndUint(t *testing.T) {
+	r := toMap([]string{"AlbumId", "Plays"}, []any{int64(2), uint64(300)})
+
+	if got := Get[int](r, "AlbumId"); !got.Valid || got.Value != 2 {
+		t.Errorf("want a valid 2 got %+v", got)
+	}
+
+	if got := Get[uint](r, "Plays"); !got.Valid || got.Value != 300 {
+		t.Errorf("want a valid 300 got %+v", got)
+	}
+
+	if got := Get[uint8](r, "Plays"); got.Valid || r.Err() == nil {
+		t.Errorf("want 300 to overflow a uint8 got %+v", got)
+	}
+}
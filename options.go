@@ -0,0 +1,243 @@
+package grepo
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// Option configures optional behaviour of a repository created with NewRepository.
+type Option func(*options)
+
+type options struct {
+	// txOptions are passed to BeginTx by Execute and WithTx, nil means the
+	// driver defaults.
+	txOptions *sql.TxOptions
+	// queryTimeout bounds each query when the caller's context has no deadline,
+	// zero means no timeout.
+	queryTimeout time.Duration
+	// strictColumns fails a query when a mapper asks for a column not in the result
+	strictColumns bool
+	// strictParams fails a query given named args it does not refer to
+	strictParams bool
+	// failOnRowMapError fails a query when a mapper ignores the errors recorded
+	// by the RowMap accessors
+	failOnRowMapError bool
+	// continueOnError makes MapRows collect mapper errors and keep mapping
+	continueOnError bool
+	// caseInsensitive lets RowMap accessors match column names regardless of case
+	caseInsensitive bool
+	// lowercaseColumns keys every RowMap by the lowercased column names
+	lowercaseColumns bool
+	// allowFullTableUpdate lets Update run without a where
+	allowFullTableUpdate bool
+	// allowFullTableDelete lets Delete run without a where
+	allowFullTableDelete bool
+	// maxRows caps the rows a query may return, zero means unlimited
+	maxRows int
+	// maxParams caps the args a query may bind, zero means the dialect's limit
+	maxParams int
+	// streamColumns are scanned as sql.RawBytes so they can be read by Reader
+	// without a copy
+	streamColumns map[string]bool
+	// nullStringError makes RowMap.String record an error for SQL NULL rather
+	// than returning ""
+	nullStringError bool
+	// converters are the functions used by Convert, keyed by their result type
+	converters converters
+	// timeLocation is the location RowMap.Time converts to, nil leaves times as read
+	timeLocation *time.Location
+	// dialect overrides the dialect detected from the driver
+	dialect Dialect
+	// logger receives the debug log of each query, nil means slog.Default
+	logger *slog.Logger
+	// unredactedArgs shows the values of args in a QueryError and in logs rather
+	// than their types
+	unredactedArgs bool
+}
+
+func newOptions(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTxOptions sets the isolation level and read-only flag used for the
+// transactions started by Execute and WithTx.
+func WithTxOptions(txOptions *sql.TxOptions) Option {
+	return func(o *options) {
+		o.txOptions = txOptions
+	}
+}
+
+// WithDefaultQueryTimeout bounds every query and Execute whose context has no
+// deadline of its own. A deadline already present on the context is left alone.
+func WithDefaultQueryTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.queryTimeout = d
+	}
+}
+
+// WithStrictColumns fails the whole query as soon as a mapper requests a column
+// which is not in the result, whether or not the mapper returns r.Err(). The
+// error lists the available columns, which makes typos and casing mismatches
+// such as ArtistId vs ArtistID easy to spot.
+func WithStrictColumns() Option {
+	return func(o *options) {
+		o.strictColumns = true
+	}
+}
+
+// WithStrictParams fails a query with named parameters when the args contain a
+// key which no :name token in the query refers to, rather than silently
+// ignoring it, which usually means a typo in the query or the key. With
+// MapRowsNStruct every exported field of the struct must be referenced.
+func WithStrictParams() Option {
+	return func(o *options) {
+		o.strictParams = true
+	}
+}
+
+// WithFailOnRowMapError checks r.Err() after every mapper returns and fails the
+// whole query when any RowMap accessor recorded an error, so a mapper which
+// forgets to return r.Err() can no longer silently drop conversion errors.
+func WithFailOnRowMapError() Option {
+	return func(o *options) {
+		o.failOnRowMapError = true
+	}
+}
+
+// WithContinueOnError makes MapRows keep mapping after a mapper returns an
+// error, for imports which process the good rows and report the bad ones. The
+// rows which mapped are returned along with an error joining the error of each
+// row which did not, prefixed with its row number counting from 1. Errors from
+// the query or the driver still stop it.
+func WithContinueOnError() Option {
+	return func(o *options) {
+		o.continueOnError = true
+	}
+}
+
+// WithCaseInsensitiveColumns lets the RowMap accessors match column names
+// regardless of case when there is no exact match, so r.Int64("artistid") and
+// r.Int64("ArtistID") both resolve a column returned as ArtistId. SQLite keeps
+// the casing of the select list while Postgres folds unquoted names to lowercase.
+func WithCaseInsensitiveColumns() Option {
+	return func(o *options) {
+		o.caseInsensitive = true
+	}
+}
+
+// WithLowercaseColumns keys every RowMap by the lowercased names of its
+// columns, so mappers can use lowercase keys whichever database they run
+// against. SQLite keeps the case of the select list, AlbumId stays AlbumId,
+// while Postgres folds unquoted names to lowercase. Unlike
+// WithCaseInsensitiveColumns the keys themselves change, as seen by Keys, ToMap
+// and MarshalJSON. Two columns which differ only by case become duplicates.
+func WithLowercaseColumns() Option {
+	return func(o *options) {
+		o.lowercaseColumns = true
+	}
+}
+
+// WithAllowFullTableUpdate lets Update run with an empty where, updating every
+// row of the table. Without it such an Update is refused.
+func WithAllowFullTableUpdate() Option {
+	return func(o *options) {
+		o.allowFullTableUpdate = true
+	}
+}
+
+// WithAllowFullTableDelete lets Delete run with an empty where, deleting every
+// row of the table. Without it such a Delete is refused.
+func WithAllowFullTableDelete() Option {
+	return func(o *options) {
+		o.allowFullTableDelete = true
+	}
+}
+
+// WithMaxRows stops any query which returns more than n rows with
+// ErrTooManyRows, guarding against a forgotten where pulling a whole table into
+// memory. Zero, the default, means unlimited.
+func WithMaxRows(n int) Option {
+	return func(o *options) {
+		o.maxRows = n
+	}
+}
+
+// WithMaxParams stops any query which binds more than n args, once slices are
+// expanded into a placeholder per element, with ErrTooManyParams rather than
+// leaving the driver to fail. The default is the limit of the dialect, 65535
+// for Postgres and MySQL and 32766 for SQLite since 3.32.0. ChunkIN splits a
+// larger IN clause into queries which fit.
+func WithMaxParams(n int) Option {
+	return func(o *options) {
+		o.maxParams = n
+	}
+}
+
+// WithStreamColumns scans the named columns as sql.RawBytes, which reference the
+// driver's buffer rather than a copy, so a mapper can stream large BLOBs with
+// RowMap.Reader. The data is only valid while the mapper runs, it is reused by
+// the next row.
+func WithStreamColumns(cols ...string) Option {
+	return func(o *options) {
+		if o.streamColumns == nil {
+			o.streamColumns = make(map[string]bool, len(cols))
+		}
+		for _, col := range cols {
+			o.streamColumns[col] = true
+		}
+	}
+}
+
+// WithUnredactedArgs includes the values of the bound args in a QueryError and
+// in log statements. By default only their types are shown, so errors can be
+// logged without leaking personal data.
+func WithUnredactedArgs() Option {
+	return func(o *options) {
+		o.unredactedArgs = true
+	}
+}
+
+// WithDialect sets the Dialect used for the SQL the repository generates, for a
+// driver whose dialect is not detected. PostgresDialect, SQLiteDialect and
+// MySQLDialect are detected from the lib/pq, mattn/go-sqlite3 and
+// go-sql-driver/mysql drivers.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithTimeLocation converts every time read with RowMap.Time to loc, such as
+// time.UTC, so results do not depend on whether the driver and column type
+// return times in UTC, local time or the offset they were written with.
+func WithTimeLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.timeLocation = loc
+	}
+}
+
+// WithNullStringError makes RowMap.String record a ColReadError for SQL NULL,
+// for mappers which treat a NULL in a text column as a bug in the data rather
+// than an empty string. By default NULL is read as "" without an error.
+func WithNullStringError() Option {
+	return func(o *options) {
+		o.nullStringError = true
+	}
+}
+
+// WithLogger sets the logger the repository writes its debug log of queries to,
+// one line per statement with the SQL as it is sent, after named parameters and
+// slices were expanded, and the number of args. The lines are only produced
+// when the logger is enabled for debug, and the arg values are shown as their
+// types unless the repository was created WithUnredactedArgs. Without it
+// slog.Default is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
@@ -0,0 +1,95 @@
+package grepo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Batch is a single query run by MapRowsParallel.
+type Batch[T any] struct {
+	SQL     string
+	Args    []any
+	MapFunc MapFunc[T]
+}
+
+// MapRowsParallel runs independent queries concurrently, at most workers at a
+// time (zero or less runs them all at once), and returns the results in the
+// order of batches. Every query takes its own connection from the pool. The
+// first error cancels the queries still running and is returned. A repository
+// bound to a transaction has a single connection, so there the queries are
+// run one after the other.
+func (repo repository[T]) MapRowsParallel(
+	ctx context.Context,
+	workers int,
+	batches []Batch[T]) ([][]*T, error) {
+
+	results := make([][]*T, len(batches))
+
+	if repo.tx != nil {
+		for i, b := range batches {
+			r, err := repo.MapRows(ctx, b.SQL, b.Args, b.MapFunc)
+			if err != nil {
+				return nil, fmt.Errorf("batch %d failed: %w", i, err)
+			}
+			results[i] = r
+		}
+		return results, nil
+	}
+
+	if workers <= 0 || workers > len(batches) {
+		workers = len(batches)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	indexes := make(chan int)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				r, err := repo.MapRows(ctx, batches[i].SQL, batches[i].Args, batches[i].MapFunc)
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("batch %d failed: %w", i, err)
+						cancel()
+					})
+					continue
+				}
+				results[i] = r
+			}
+		}()
+	}
+
+feed:
+	for i := range batches {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// the caller's context may have ended before every batch was handed out
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
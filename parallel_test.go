@@ -0,0 +1,37 @@
+package grepo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMapRowsParallel(t *testing.T) {
+	results, err := albums.MapRowsParallel(context.Background(), 2, []Batch[Album]{
+		{SQL: "select AlbumId, Title, ArtistId from Album where AlbumId < $1", Args: []any{4}, MapFunc: albumMapper},
+		{SQL: "select AlbumId, Title, ArtistId from Album where AlbumId = $1", Args: []any{1}, MapFunc: albumMapper},
+		{SQL: "select AlbumId, Title, ArtistId from Album where ArtistId = $1", Args: []any{1}, MapFunc: albumMapper},
+	})
+
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	want := []int{3, 1, 2}
+	for i, r := range results {
+		if len(r) != want[i] {
+			t.Errorf("want %d rows for batch %d got %d", want[i], i, len(r))
+		}
+	}
+}
+
+func TestMapRowsParallelError(t *testing.T) {
+	_, err := albums.MapRowsParallel(context.Background(), 2, []Batch[Album]{
+		{SQL: "select AlbumId, Title, ArtistId from Album", MapFunc: albumMapper},
+		{SQL: "select AlbumId from NoSuchTable", MapFunc: albumMapper},
+		{SQL: slowQuery, MapFunc: albumIdMapper},
+	})
+
+	if err == nil {
+		t.Errorf("want error from the failing batch got nil")
+	}
+}
@@ -0,0 +1,67 @@
+package grepo
+
+import "github.com/lib/pq"
+
+// IntSlice attempts to read the value within the RowMap with the provided key
+// (k) as a []int64. Postgres int[] columns arrive either as a pq.Int64Array (or
+// []int64) or, when scanned without a typed destination, as the brace-delimited
+// text form such as {1,2,3}. Both are accepted.
+// SQL NULL returns nil without recording an error.
+// If the value cannot be read, then nil is returned.
+func (m *RowMap) IntSlice(k string) []int64 {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return nil
+	}
+
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case pq.Int64Array:
+		return v
+	case []int64:
+		return v
+	case []byte, string:
+		var a pq.Int64Array
+		if err := a.Scan(v); err == nil {
+			return a
+		}
+	}
+
+	m.addErr(NewColReadError(k, val, "[]int64"))
+	return nil
+}
+
+// StringSlice attempts to read the value within the RowMap with the provided
+// key (k) as a []string. Postgres text[] columns arrive either as a
+// pq.StringArray (or []string) or as the brace-delimited text form such as
+// {a,"b c"}, quoted elements are unescaped.
+// SQL NULL returns nil without recording an error.
+// If the value cannot be read, then nil is returned.
+func (m *RowMap) StringSlice(k string) []string {
+	val, err := m.try(k)
+
+	if err != nil {
+		m.addErr(err)
+		return nil
+	}
+
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case pq.StringArray:
+		return v
+	case []string:
+		return v
+	case []byte, string:
+		var a pq.StringArray
+		if err := a.Scan(v); err == nil {
+			return a
+		}
+	}
+
+	m.addErr(NewColReadError(k, val, "[]string"))
+	return nil
+}
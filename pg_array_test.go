@@ -0,0 +1,47 @@
+package grepo
+
+import (
+	"github.com/lib/pq"
+	"reflect"
+	"testing"
+)
+
+func TestIntSlice(t *testing.T) {
+	r := toMap(
+		[]string{"text", "array", "invalid"},
+		[]any{[]byte("{1,2,3}"), pq.Int64Array{4, 5}, "{a,b}"})
+
+	if got := r.IntSlice("text"); !reflect.DeepEqual(got, []int64{1, 2, 3}) {
+		t.Errorf("want [1 2 3] got %v", got)
+	}
+
+	if got := r.IntSlice("array"); !reflect.DeepEqual(got, []int64{4, 5}) {
+		t.Errorf("want [4 5] got %v", got)
+	}
+
+	if err := r.Err(); err != nil {
+		t.Errorf("want no errors got %v", err)
+	}
+
+	if got := r.IntSlice("invalid"); got != nil || r.Err() == nil {
+		t.Errorf("want nil and an error got %v and %v", got, r.Err())
+	}
+}
+
+func TestStringSlice(t *testing.T) {
+	r := toMap(
+		[]string{"text", "array"},
+		[]any{`{rock,"heavy metal"}`, pq.StringArray{"jazz"}})
+
+	if got := r.StringSlice("text"); !reflect.DeepEqual(got, []string{"rock", "heavy metal"}) {
+		t.Errorf("want [rock heavy metal] got %v", got)
+	}
+
+	if got := r.StringSlice("array"); !reflect.DeepEqual(got, []string{"jazz"}) {
+		t.Errorf("want [jazz] got %v", got)
+	}
+
+	if err := r.Err(); err != nil {
+		t.Errorf("want no errors got %v", err)
+	}
+}
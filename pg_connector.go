@@ -27,56 +27,103 @@ type PostgresConnector struct {
 	database Database
 	db       *sql.DB
 	mu       sync.Mutex
+	// backoff is the wait before the first retry, doubled for each one after
+	backoff time.Duration
 }
 
+// NewPostgresConnector returns a connector for database. Unlike
+// NewConnectorFromDSN it cannot fail, so a Provider with no registered driver,
+// such as pgx without the import _ "github.com/jackc/pgx/v5/stdlib", is only
+// reported by the first GetConnection.
 func NewPostgresConnector(database Database) *PostgresConnector {
 	return &PostgresConnector{
 		database: database,
+		backoff:  time.Second,
 	}
 }
 
+// GetConnection connects on first use, retrying with an exponential backoff,
+// and returns the same handle on subsequent calls. The cached handle is not
+// pinged, database/sql redials the broken connections of its pool itself, so
+// a handle held by a repository keeps working once the server is back. Only a
+// handle which has been closed is replaced, by a new connection, and it is
+// never closed by the connector except by Close.
 func (c *PostgresConnector) GetConnection() (*sql.DB, error) {
 	c.mu.Lock()
-	if c.db != nil {
-		c.mu.Unlock()
-		return c.db, nil
-	}
+	cached := c.db
 	c.mu.Unlock()
 
+	if cached != nil && !isClosed(cached) {
+		return cached, nil
+	}
+
+	return c.reconnect(cached)
+}
+
+// reconnect makes a new connection in place of stale, which is nil on first
+// use and has been closed otherwise, retrying with an exponential backoff.
+func (c *PostgresConnector) reconnect(stale *sql.DB) (*sql.DB, error) {
+	if err := checkDriver(c.database.Provider); err != nil {
+		return nil, err
+	}
+
 	maxRetries := 3
 	var lastErr error
 
 	for i := 0; i < maxRetries; i++ {
 		db, err := c.tryConnect()
 		if err == nil {
-			c.mu.Lock()
-			c.db = db
-			c.mu.Unlock()
-			return db, nil
+			return c.replace(stale, db), nil
 		}
-		lastErr = err
+		// the driver's error may repeat the connection string, password and all
+		lastErr = redactError(err, c.database.Password)
 
 		slog.Warn(fmt.Sprintf("failed to connect after %d attempts: %v", maxRetries, lastErr))
 		slog.Warn(fmt.Sprintf("retrying...\n"))
 
-		backoffDuration := time.Second * time.Duration(1<<uint(i)) // exponential backoff
+		backoffDuration := c.backoff * time.Duration(1<<uint(i)) // exponential backoff
 		time.Sleep(backoffDuration)
 	}
 
-	return nil, fmt.Errorf("failed to connect after %d attempts: %v", maxRetries, lastErr)
+	return nil, fmt.Errorf("failed to connect after %d attempts: %w", maxRetries, lastErr)
 }
 
-func (c *PostgresConnector) tryConnect() (*sql.DB, error) {
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+// replace caches db in place of stale. If another caller has already replaced
+// stale, db is closed instead and their handle is returned.
+func (c *PostgresConnector) replace(stale *sql.DB, db *sql.DB) *sql.DB {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db != stale {
+		_ = db.Close()
+		return c.db
+	}
+
+	c.db = db
+	return db
+}
+
+// redact masks the configured password within s.
+func (c *PostgresConnector) redact(s string) string {
+	return redactSecret(redactDSN(s), c.database.Password)
+}
+
+// dsn builds the connection string for the configured Database. This is all the
+// typed connector adds over NewConnectorFromDSN, use that instead when more
+// connection parameters are needed.
+func (c *PostgresConnector) dsn() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		c.database.Host,
 		c.database.Port,
 		c.database.User,
 		c.database.Password,
 		c.database.Db,
 	)
+}
 
+func (c *PostgresConnector) tryConnect() (*sql.DB, error) {
 	// Reminder, this does not n
-	db, err := sql.Open(c.database.Provider, connStr)
+	db, err := sql.Open(c.database.Provider, c.dsn())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -1,9 +1,149 @@
 package grepo
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
 
 // gotta test with an actual db in this case.
 
 func TestNewPostgresConnector(t *testing.T) {
 
 }
+
+func TestPostgresConnectorRedactsPassword(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	c := NewPostgresConnector(Database{
+		Host:     "unreachable",
+		Port:     5432,
+		User:     "grepo",
+		Password: "s3cret",
+		Provider: "grepo-fake",
+		Db:       "chinook",
+	})
+	c.backoff = time.Millisecond
+
+	_, err := c.GetConnection()
+	if err == nil {
+		t.Fatalf("want a connection error got nil")
+	}
+
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("want the password redacted from the error got %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "unreachable") {
+		t.Fatalf("want the connection failure logged got %s", logs.String())
+	}
+
+	if strings.Contains(logs.String(), "s3cret") {
+		t.Errorf("want the password redacted from the logs got %s", logs.String())
+	}
+}
+
+func TestPostgresConnectorReconnects(t *testing.T) {
+	c := NewPostgresConnector(Database{Host: "localhost", Provider: "grepo-fake"})
+	defer func() { _ = c.Close() }()
+
+	db, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("failed to connect %v", err)
+	}
+
+	if again, _ := c.GetConnection(); again != db {
+		t.Errorf("want the cached handle while it is alive")
+	}
+
+	_ = db.Close()
+
+	// a closed handle is replaced on the next GetConnection
+	reconnected, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("failed to reconnect %v", err)
+	}
+
+	if reconnected == db {
+		t.Fatalf("want a new handle after the cached one was closed")
+	}
+
+	if err = reconnected.Ping(); err != nil {
+		t.Errorf("want a live handle got %v", err)
+	}
+}
+
+func TestPostgresConnectorOutage(t *testing.T) {
+	c := NewPostgresConnector(Database{Host: "flaky", Provider: "grepo-fake"})
+	c.backoff = time.Millisecond
+	defer func() { _ = c.Close() }()
+
+	repo, err := NewRepositoryFromConnector[Album](c)
+	if err != nil {
+		t.Fatalf("failed to create repository %v", err)
+	}
+
+	fakeOutage.Store(true)
+	_, outageErr := repo.ExecuteNoTx(context.Background(), "delete from Album", nil)
+	db, err := c.GetConnection()
+	fakeOutage.Store(false)
+
+	if outageErr == nil {
+		t.Fatalf("want an error during the outage")
+	}
+
+	if err != nil {
+		t.Fatalf("want the cached handle during the outage got %v", err)
+	}
+
+	// the repository keeps its handle, which redials once the server is back
+	if _, err = repo.ExecuteNoTx(context.Background(), "delete from Album", nil); err != nil {
+		t.Errorf("want the repository to work after the outage got %v", err)
+	}
+
+	if again, _ := c.GetConnection(); again != db {
+		t.Errorf("want the same handle after the outage")
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	errOpen := errors.New("fake: open failed")
+	err := redactError(fmt.Errorf("%w with host=db password=s3cret", errOpen), "s3cret")
+
+	if !errors.Is(err, errOpen) {
+		t.Errorf("want the original error wrapped got %v", err)
+	}
+
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("want the password redacted got %v", err)
+	}
+
+	if redactError(errOpen, "") != errOpen {
+		t.Errorf("want an error without secrets returned as it is")
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	table := []struct {
+		dsn  string
+		want string
+	}{
+		{"host=db user=grepo password=s3cret dbname=chinook", "host=db user=grepo password=***** dbname=chinook"},
+		{"host=db password='s3 cret' dbname=chinook", "host=db password=***** dbname=chinook"},
+		{"postgres://grepo:s3cret@db:5432/chinook", "postgres://grepo:*****@db:5432/chinook"},
+		{"file:chinook.sqlite?_journal_mode=WAL", "file:chinook.sqlite?_journal_mode=WAL"},
+	}
+
+	for _, a := range table {
+		if got := redactDSN(a.dsn); got != a.want {
+			t.Errorf("want `%s` got `%s`", a.want, got)
+		}
+	}
+}
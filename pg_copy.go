@@ -0,0 +1,92 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// CopyFrom bulk loads rows into the columns of table with the Postgres COPY
+// protocol, which is far faster than inserting row by row, and returns the
+// number of rows loaded. table may be qualified by a schema. The load runs in
+// the repository's transaction when it is bound to one, otherwise in its own,
+// so either every row is loaded or none are. Only Postgres supports COPY.
+func (repo repository[T]) CopyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	if _, ok := repo.dialect.(PostgresDialect); !ok {
+		return 0, fmt.Errorf("func CopyFrom() requires Postgres, the repository dialect is %T", repo.dialect)
+	}
+
+	if err := checkIdent(append([]string{table}, columns...)...); err != nil {
+		return 0, err
+	}
+
+	if len(columns) == 0 {
+		return 0, errors.New("func CopyFrom() requires at least one column")
+	}
+
+	ctx, cancel := repo.withTimeout(ctx)
+	defer cancel()
+
+	if repo.tx != nil {
+		return copyIn(ctx, repo.tx, table, columns, rows)
+	}
+
+	tx, err := repo.database.BeginTx(ctx, repo.options.txOptions)
+	if err != nil {
+		slog.Error(fmt.Sprintf("unable to begin a transaction CopyFrom() %v", err))
+		return 0, fmt.Errorf("func CopyFrom() failed to begin a transaction: %w", err)
+	}
+
+	n, err := copyIn(ctx, tx, table, columns, rows)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.Error(fmt.Sprintf("error executing commit in CopyFrom() %v", err))
+		return 0, fmt.Errorf("func CopyFrom() failed during Commit: %w", err)
+	}
+
+	return n, nil
+}
+
+// copyIn sends rows with a COPY statement prepared on tx.
+func copyIn(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) (int64, error) {
+	statement := pq.CopyIn(table, columns...)
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		statement = pq.CopyInSchema(schema, name, columns...)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, statement)
+	if err != nil {
+		return 0, fmt.Errorf("func CopyFrom() failed to prepare the copy: %w", err)
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("error closing statement %w", "err", err.Error())
+		}
+	}()
+
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("func CopyFrom() row %d has %d value(s) for %d column(s)", i, len(row), len(columns))
+		}
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			return 0, fmt.Errorf("func CopyFrom() failed on row %d: %w", i, err)
+		}
+	}
+
+	// an Exec without args flushes the buffered rows and ends the copy
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return 0, fmt.Errorf("func CopyFrom() failed to complete the copy: %w", err)
+	}
+
+	return int64(len(rows)), nil
+}
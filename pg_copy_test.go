@@ -0,0 +1,59 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestCopyFromRequiresPostgres(t *testing.T) {
+	_, err := albums.CopyFrom(context.Background(), "Artist", []string{"Name"}, [][]any{{"grepo"}})
+
+	if err == nil {
+		t.Errorf("want error copying into sqlite got nil")
+	}
+}
+
+// TestCopyFrom needs a Postgres server, see TestListen.
+func TestCopyFrom(t *testing.T) {
+	host := os.Getenv("GREPO_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("GREPO_POSTGRES_HOST is not set")
+	}
+
+	db, err := sql.Open("postgres", fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, envOr("GREPO_POSTGRES_USER", "postgres"), os.Getenv("GREPO_POSTGRES_PASSWORD"), envOr("GREPO_POSTGRES_DB", "postgres")))
+	if err != nil {
+		t.Fatalf("failed to connect %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, err = db.Exec(`create temporary table artist (artist_id serial primary key, name text)`); err != nil {
+		t.Fatalf("failed to create table %v", err)
+	}
+
+	rows := make([][]any, 1000)
+	for i := range rows {
+		rows[i] = []any{fmt.Sprintf("grepo-%d", i)}
+	}
+
+	// a temporary table is only visible to its own connection
+	db.SetMaxOpenConns(1)
+	artists := NewRepository[Album](db)
+
+	n, err := artists.CopyFrom(context.Background(), "artist", []string{"name"}, rows)
+	if err != nil {
+		t.Fatalf("failed to copy %v", err)
+	}
+
+	var count int64
+	if err = db.QueryRow(`select count(*) from artist`).Scan(&count); err != nil {
+		t.Fatalf("failed to count rows %v", err)
+	}
+
+	if n != 1000 || count != 1000 {
+		t.Errorf("want 1000 rows loaded got %d and %d counted", n, count)
+	}
+}
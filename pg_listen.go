@@ -0,0 +1,100 @@
+package grepo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification is a message delivered by a Postgres NOTIFY.
+type Notification struct {
+	// Channel is the channel the notification was sent on
+	Channel string
+	// Payload is the optional text sent with the notification
+	Payload string
+	// PID is the process id of the backend which sent it
+	PID int
+}
+
+// listenerPingInterval is how long the listener may be idle before its
+// connection is checked, a dropped connection is otherwise only noticed when
+// the next notification fails to arrive.
+const listenerPingInterval = 90 * time.Second
+
+// Listen subscribes to the Postgres channels using a dedicated connection and
+// delivers their notifications over the returned channel until ctx is
+// cancelled, when the connection is closed and the channel with it. When the
+// connection drops it is re-established, backing off up to a minute between
+// attempts, and the channels are listened to again. Notifications sent while
+// the connection was down are lost.
+func (c *PostgresConnector) Listen(ctx context.Context, channels ...string) (<-chan Notification, error) {
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("listen requires at least one channel")
+	}
+
+	listener := pq.NewListener(c.dsn(), 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn(fmt.Sprintf("postgres listener event %d: %v", event, c.redact(err.Error())))
+		}
+	})
+
+	for _, channel := range channels {
+		if err := listener.Listen(channel); err != nil {
+			_ = listener.Close()
+			return nil, fmt.Errorf("failed to listen on channel %s: %w", channel, redactError(err, c.database.Password))
+		}
+	}
+
+	out := make(chan Notification)
+
+	go func() {
+		defer func() {
+			if err := listener.Close(); err != nil {
+				slog.Error("error closing postgres listener", "err", err)
+			}
+		}()
+		forwardNotifications(ctx, listener.Notify, out, func() {
+			go func() { _ = listener.Ping() }()
+		})
+	}()
+
+	return out, nil
+}
+
+// forwardNotifications copies notifications from in to out until ctx is
+// cancelled or in is closed, then closes out. pq sends a nil notification after
+// reconnecting, which is dropped. ping is called whenever the listener has been
+// idle for listenerPingInterval.
+func forwardNotifications(ctx context.Context, in <-chan *pq.Notification, out chan<- Notification, ping func()) {
+	defer close(out)
+
+	idle := time.NewTimer(listenerPingInterval)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-idle.C:
+			ping()
+			idle.Reset(listenerPingInterval)
+		case n, ok := <-in:
+			if !ok {
+				return
+			}
+			idle.Reset(listenerPingInterval)
+			if n == nil {
+				continue
+			}
+
+			select {
+			case out <- Notification{Channel: n.Channel, Payload: n.Extra, PID: n.BePid}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package grepo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestForwardNotifications(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan *pq.Notification, 2)
+	out := make(chan Notification)
+
+	// the nil is what pq sends after reconnecting
+	in <- nil
+	in <- &pq.Notification{Channel: "albums", Extra: "347", BePid: 1}
+
+	go forwardNotifications(ctx, in, out, func() {})
+
+	select {
+	case n := <-out:
+		if n.Channel != "albums" || n.Payload != "347" {
+			t.Errorf("want the albums notification got %+v", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("want a notification got none")
+	}
+
+	cancel()
+	if _, ok := <-out; ok {
+		t.Errorf("want the channel closed after cancelling")
+	}
+}
+
+// TestListen needs a Postgres server, set GREPO_POSTGRES_HOST (and the other
+// GREPO_POSTGRES_* variables when the defaults are wrong) to run it.
+func TestListen(t *testing.T) {
+	host := os.Getenv("GREPO_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("GREPO_POSTGRES_HOST is not set")
+	}
+
+	c := NewPostgresConnector(Database{
+		Host:     host,
+		Port:     5432,
+		User:     envOr("GREPO_POSTGRES_USER", "postgres"),
+		Password: os.Getenv("GREPO_POSTGRES_PASSWORD"),
+		Provider: "postgres",
+		Db:       envOr("GREPO_POSTGRES_DB", "postgres"),
+	})
+	defer func() { _ = c.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	notifications, err := c.Listen(ctx, "grepo_albums")
+	if err != nil {
+		t.Fatalf("failed to listen %v", err)
+	}
+
+	db, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("failed to connect %v", err)
+	}
+
+	if _, err = db.Exec(`select pg_notify('grepo_albums', $1)`, "347"); err != nil {
+		t.Fatalf("failed to notify %v", err)
+	}
+
+	select {
+	case n := <-notifications:
+		if n.Payload != "347" {
+			t.Errorf("want payload 347 got %s", n.Payload)
+		}
+	case <-ctx.Done():
+		t.Fatalf("want a notification got none")
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
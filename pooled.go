@@ -0,0 +1,69 @@
+package grepo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// FillFunc maps a row into t, an instance handed out by the acquire function
+// of MapRowsPooled, rather than allocating a new T.
+type FillFunc[T any] func(r *RowMap, t *T) error
+
+// MapRowsPooled executes the query like MapRows, but fills instances taken from
+// acquire, typically the Get of a sync.Pool, instead of allocating a T for
+// every row, for high throughput queries whose results are short-lived:
+//
+//	pool := sync.Pool{New: func() any { return new(Album) }}
+//	acquire := func() *Album { return pool.Get().(*Album) }
+//	reset := func(a *Album) { *a = Album{} }
+//
+//	albums, err := repo.MapRowsPooled(ctx, query, nil, acquire, reset, fill)
+//	defer func() {
+//		for _, a := range albums {
+//			pool.Put(a)
+//		}
+//	}()
+//
+// reset is called on every instance before fill, so nothing a previous user
+// left behind leaks into the row. The returned instances belong to the caller,
+// who returns them to the pool once they are no longer used, and must not
+// keep any reference to them afterwards. If the query fails no instances are
+// returned, those already acquired are left to the garbage collector.
+func (repo repository[T]) MapRowsPooled(
+	ctx context.Context,
+	sql string,
+	args []any,
+	acquire func() *T,
+	reset func(*T),
+	fill FillFunc[T]) ([]*T, error) {
+
+	if acquire == nil || reset == nil || fill == nil {
+		return nil, fmt.Errorf("func MapRowsPooled() requires acquire, reset and fill functions")
+	}
+
+	mapFunc := func(r *RowMap) (*T, error) {
+		t := acquire()
+		reset(t)
+		return t, fill(r, t)
+	}
+
+	var results []*T
+
+	err := repo.eachRow(ctx, sql, args, func(rowMap *RowMap) error {
+		r, err := repo.mapRow(mapFunc, rowMap)
+		if err != nil {
+			return err
+		}
+		results = append(results, r)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("MapRowsPooled resulted in %d row(s)", "grepo", len(results))
+
+	return results, nil
+}
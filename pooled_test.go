@@ -0,0 +1,74 @@
+package grepo
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+var albumPool = sync.Pool{New: func() any { return new(Album) }}
+
+func acquireAlbum() *Album {
+	return albumPool.Get().(*Album)
+}
+
+func resetAlbum(a *Album) {
+	*a = Album{}
+}
+
+func fillAlbum(r *RowMap, a *Album) error {
+	a.AlbumID = r.Int64("AlbumId")
+	a.Title = r.String("Title")
+	a.ArtistID = r.Int32("ArtistId")
+	return r.Err()
+}
+
+func TestMapRowsPooled(t *testing.T) {
+	stale := &Album{AlbumID: 99, Title: "stale", ArtistID: 99}
+	acquired := 0
+	acquire := func() *Album {
+		acquired++
+		if acquired == 1 {
+			return stale
+		}
+		return acquireAlbum()
+	}
+
+	// ArtistId is not read, so only reset clears the stale value
+	fill := func(r *RowMap, a *Album) error {
+		a.AlbumID = r.Int64("AlbumId")
+		a.Title = r.String("Title")
+		return r.Err()
+	}
+
+	results, err := albums.MapRowsPooled(context.Background(),
+		"select AlbumId, Title from Album where AlbumId < $1 order by AlbumId", []any{3}, acquire, resetAlbum, fill)
+	if err != nil {
+		t.Fatalf("failed to map rows %v", err)
+	}
+
+	if len(results) != 2 || acquired != 2 {
+		t.Fatalf("want 2 rows from 2 acquired instances got %d and %d", len(results), acquired)
+	}
+
+	if results[0] != stale || results[0].AlbumID != 1 || results[0].ArtistID != 0 {
+		t.Errorf("want the stale instance reset and refilled got %+v", results[0])
+	}
+
+	if results[1].Title != "Balls to the Wall" {
+		t.Errorf("want Balls to the Wall got %s", results[1].Title)
+	}
+}
+
+func BenchmarkMapRowsPooled(b *testing.B) {
+	for b.Loop() {
+		results, err := albums.MapRowsPooled(context.Background(), "select AlbumId, Title, ArtistId from Album", nil,
+			acquireAlbum, resetAlbum, fillAlbum)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, a := range results {
+			albumPool.Put(a)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// PreparedQuery is a query bound to its mapper, defined once and executed with
+// different named parameters.
+type PreparedQuery[T any] interface {
+	// One executes the query and maps at most one row, more than one row is an error.
+	One(ctx context.Context, args map[string]any) (*T, error)
+
+	// Many executes the query and maps every row.
+	Many(ctx context.Context, args map[string]any) ([]*T, error)
+
+	// ArgsFor returns the flattened args the query would be executed with for args, without executing it.
+	ArgsFor(args map[string]any) ([]any, error)
+
+	// Close releases the prepared statements.
+	Close() error
+}
+
+// preparedQuery caches a prepared *sql.Stmt for every distinct rewritten query.
+// Slices expand to a different number of placeholders depending on their
+// length, so one named query may need several statements.
+type preparedQuery[T any] struct {
+	repo    repository[T]
+	sql     string
+	mapFunc MapFunc[T]
+	mu      sync.Mutex
+	stmts   map[string]*sql.Stmt
+}
+
+// Prepare returns a PreparedQuery for query, which uses named parameters, and
+// mapFunc. Statements are prepared on first use and kept until Close.
+func (repo repository[T]) Prepare(query string, mapFunc MapFunc[T]) PreparedQuery[T] {
+	return &preparedQuery[T]{
+		repo:    repo,
+		sql:     query,
+		mapFunc: mapFunc,
+		stmts:   make(map[string]*sql.Stmt),
+	}
+}
+
+func (q *preparedQuery[T]) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if stmt, ok := q.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := q.repo.conn().PrepareContext(ctx, query)
+	if err != nil {
+		slog.Error("error preparing statement", "err", err.Error())
+		return nil, q.repo.queryError(query, nil, err)
+	}
+
+	q.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (q *preparedQuery[T]) Many(ctx context.Context, args map[string]any) ([]*T, error) {
+	var results []*T
+
+	err := q.eachRow(ctx, args, func(rowMap *RowMap) error {
+		r, err := q.repo.mapRow(q.mapFunc, rowMap)
+		if err != nil {
+			return err
+		}
+		results = append(results, r)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// One stops reading as soon as a second row arrives, like MapRow.
+func (q *preparedQuery[T]) One(ctx context.Context, args map[string]any) (*T, error) {
+	var result *T
+	found := false
+
+	err := q.eachRow(ctx, args, func(rowMap *RowMap) error {
+		if found {
+			return fmt.Errorf("One expected 0 or 1 rows: %w", ErrTooManyRows)
+		}
+		r, err := q.repo.mapRow(q.mapFunc, rowMap)
+		if err != nil {
+			return err
+		}
+		result, found = r, true
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// eachRow executes the statement for args and calls fn with a RowMap for every
+// row returned, stopping at the first error.
+func (q *preparedQuery[T]) eachRow(ctx context.Context, args map[string]any, fn func(rowMap *RowMap) error) error {
+	if err := checkQuery(q.sql); err != nil {
+		return err
+	}
+
+	query, newArgs, err := q.repo.bind(q.sql, args)
+	if err != nil {
+		return err
+	}
+	if err = q.repo.checkArgs(query, newArgs); err != nil {
+		return err
+	}
+	newArgs = q.repo.bindArgs(newArgs)
+
+	ctx, cancel := q.repo.withTimeout(ctx)
+	defer cancel()
+
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	q.repo.logQuery(ctx, query, newArgs)
+	rows, err := stmt.QueryContext(ctx, newArgs...)
+	if err != nil {
+		return q.repo.queryError(query, newArgs, err)
+	}
+
+	return q.repo.scanRows(rows, fn)
+}
+
+// ArgsFor binds args the same way as One and Many, with slices expanded into
+// an arg per element in placeholder order, and returns the args the statement
+// would be executed with, for debugging a binding without running the query.
+// The values are returned as given, before any driver specific formatting.
+func (q *preparedQuery[T]) ArgsFor(args map[string]any) ([]any, error) {
+	_, newArgs, err := q.repo.bind(q.sql, args)
+	if err != nil {
+		return nil, err
+	}
+	return newArgs, nil
+}
+
+func (q *preparedQuery[T]) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var errs []error
+	for query, stmt := range q.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(q.stmts, query)
+	}
+
+	return errors.Join(errs...)
+}
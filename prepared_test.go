@@ -0,0 +1,73 @@
+package grepo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPrepare(t *testing.T) {
+	byId := albums.Prepare("select AlbumId, Title, ArtistId from Album where AlbumId = :id", albumMapper)
+	defer func() { _ = byId.Close() }()
+
+	for _, id := range []int64{1, 2, 3} {
+		album, err := byId.One(context.Background(), map[string]any{"id": id})
+		if err != nil {
+			t.Fatalf("error retrieving album %d %v", id, err)
+		}
+		if album == nil || album.AlbumID != id {
+			t.Errorf("want album %d got %+v", id, album)
+		}
+	}
+
+	byIds := albums.Prepare("select AlbumId, Title, ArtistId from Album where AlbumId in ( :ids )", albumMapper)
+	defer func() { _ = byIds.Close() }()
+
+	for _, ids := range [][]int{{1, 2}, {1, 2, 3}, {4, 5}} {
+		results, err := byIds.Many(context.Background(), map[string]any{"ids": ids})
+		if err != nil {
+			t.Fatalf("error retrieving albums %v %v", ids, err)
+		}
+		if len(results) != len(ids) {
+			t.Errorf("want %d albums got %d", len(ids), len(results))
+		}
+	}
+
+	if n := len(byIds.(*preparedQuery[Album]).stmts); n != 2 {
+		t.Errorf("want a statement per distinct expansion, 2, got %d", n)
+	}
+
+	if _, err := byIds.One(context.Background(), map[string]any{"ids": []int{1, 2}}); !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("want ErrTooManyRows from One for more than one row got %v", err)
+	}
+
+	mapped := 0
+	every := albums.Prepare("select AlbumId, Title, ArtistId from Album where AlbumId > :after", func(r *RowMap) (*Album, error) {
+		mapped++
+		return albumMapper(r)
+	})
+	defer func() { _ = every.Close() }()
+
+	if _, err := every.One(context.Background(), map[string]any{"after": 0}); !errors.Is(err, ErrTooManyRows) || mapped != 1 {
+		t.Errorf("want One to stop at the second row got %d mapped and %v", mapped, err)
+	}
+}
+
+func TestArgsFor(t *testing.T) {
+	q := albums.Prepare("select AlbumId, Title, ArtistId from Album where ArtistId = :artist and AlbumId in ( :ids )", albumMapper)
+	defer func() { _ = q.Close() }()
+
+	args, err := q.ArgsFor(map[string]any{"ids": []int{1, 4}, "artist": 1})
+	if err != nil {
+		t.Fatalf("failed to bind args %v", err)
+	}
+
+	if !reflect.DeepEqual(args, []any{1, 1, 4}) {
+		t.Errorf("want [1 1 4] got %v", args)
+	}
+
+	if n := len(q.(*preparedQuery[Album]).stmts); n != 0 {
+		t.Errorf("want nothing prepared by ArgsFor got %d statement(s)", n)
+	}
+}
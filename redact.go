@@ -0,0 +1,88 @@
+package grepo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secrets in log statements and errors.
+const redacted = "*****"
+
+var (
+	// dsnPasswordRe matches the password of a key=value connection string
+	dsnPasswordRe = regexp.MustCompile(`(?i)(password=)('[^']*'|\S+)`)
+	// urlPasswordRe matches the password of a URL connection string
+	urlPasswordRe = regexp.MustCompile(`(://[^:/@\s]*:)[^@\s]*@`)
+)
+
+// redactDSN masks the password in both key=value and URL style connection
+// strings.
+func redactDSN(dsn string) string {
+	dsn = dsnPasswordRe.ReplaceAllString(dsn, "${1}"+redacted)
+	return urlPasswordRe.ReplaceAllString(dsn, "${1}"+redacted+"@")
+}
+
+// redactSecret masks every occurrence of secret in s, for error messages from a
+// driver which may repeat the connection string it was given.
+func redactSecret(s string, secret string) string {
+	if secret == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, secret, redacted)
+}
+
+// redactedError is an error whose message has its secrets masked, which still
+// unwraps to the original so errors.Is and errors.As see through it.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e redactedError) Error() string {
+	return e.msg
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError masks the password of any connection string repeated in the
+// message of err, and every occurrence of secret, the way the connectors do.
+func redactError(err error, secret string) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := redactSecret(redactDSN(err.Error()), secret)
+	if msg == err.Error() {
+		return err
+	}
+	return redactedError{err: err, msg: msg}
+}
+
+// redactArgs renders args for a log or error, as their types unless unredacted.
+func redactArgs(args []any, unredacted bool) []string {
+	view := make([]string, len(args))
+	for i, arg := range args {
+		if unredacted {
+			view[i] = fmt.Sprintf("%v", arg)
+		} else {
+			view[i] = fmt.Sprintf("<%T>", arg)
+		}
+	}
+	return view
+}
+
+// redactNamed is redactArgs for named args.
+func redactNamed(args map[string]any, unredacted bool) map[string]string {
+	view := make(map[string]string, len(args))
+	for k, arg := range args {
+		if unredacted {
+			view[k] = fmt.Sprintf("%v", arg)
+		} else {
+			view[k] = fmt.Sprintf("<%T>", arg)
+		}
+	}
+	return view
+}
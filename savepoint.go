@@ -0,0 +1,48 @@
+package grepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotInTx is returned by the savepoint methods of a repository which is not
+// bound to a transaction by WithTx.
+var ErrNotInTx = errors.New("savepoints require a repository bound to a transaction by WithTx")
+
+// Savepoint marks a point within the current transaction which RollbackTo can
+// return to, undoing only the work done since, without abandoning the whole
+// transaction.
+func (repo repository[T]) Savepoint(ctx context.Context, name string) error {
+	return repo.savepoint(ctx, "savepoint %s", name)
+}
+
+// RollbackTo undoes the work done since the savepoint name. The savepoint
+// remains, so it can be rolled back to again.
+func (repo repository[T]) RollbackTo(ctx context.Context, name string) error {
+	return repo.savepoint(ctx, "rollback to savepoint %s", name)
+}
+
+// ReleaseSavepoint discards the savepoint name, keeping the work done since it.
+func (repo repository[T]) ReleaseSavepoint(ctx context.Context, name string) error {
+	return repo.savepoint(ctx, "release savepoint %s", name)
+}
+
+// savepoint executes the savepoint statement format for name within the
+// repository's transaction.
+func (repo repository[T]) savepoint(ctx context.Context, format string, name string) error {
+	if repo.tx == nil {
+		return ErrNotInTx
+	}
+
+	if err := checkUnqualified(name); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(format, repo.dialect.QuoteIdent(name))
+	if _, err := repo.tx.ExecContext(ctx, query); err != nil {
+		return repo.queryError(query, nil, err)
+	}
+
+	return nil
+}
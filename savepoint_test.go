@@ -0,0 +1,65 @@
+package grepo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSavepoint(t *testing.T) {
+	ctx := context.Background()
+	defer func() { _, _ = testDB.Exec(`delete from Artist where Name like 'grepo-savepoint%'`) }()
+
+	err := albums.WithTx(ctx, func(tx Repository[Album]) error {
+		if _, err := tx.Execute(ctx, `insert into Artist ("name") values ($1)`, []any{"grepo-savepoint-kept"}); err != nil {
+			return err
+		}
+
+		if err := tx.Savepoint(ctx, "before_discarded"); err != nil {
+			return err
+		}
+
+		if _, err := tx.Execute(ctx, `insert into Artist ("name") values ($1)`, []any{"grepo-savepoint-discarded"}); err != nil {
+			return err
+		}
+
+		if err := tx.RollbackTo(ctx, "before_discarded"); err != nil {
+			return err
+		}
+
+		return tx.ReleaseSavepoint(ctx, "before_discarded")
+	})
+
+	if err != nil {
+		t.Fatalf("failed transaction %v", err)
+	}
+
+	for name, want := range map[string]int64{"grepo-savepoint-kept": 1, "grepo-savepoint-discarded": 0} {
+		var count int64
+		if err := testDB.QueryRow(`select count(*) from Artist where Name = $1`, name).Scan(&count); err != nil {
+			t.Fatalf("failed to count rows %v", err)
+		}
+		if count != want {
+			t.Errorf("want %d rows named %s got %d", want, name, count)
+		}
+	}
+}
+
+func TestSavepointOutsideTx(t *testing.T) {
+	if err := albums.Savepoint(context.Background(), "outside"); !errors.Is(err, ErrNotInTx) {
+		t.Errorf("want ErrNotInTx got %v", err)
+	}
+}
+
+func TestSavepointQualifiedName(t *testing.T) {
+	ctx := context.Background()
+
+	err := albums.WithTx(ctx, func(tx Repository[Album]) error {
+		return tx.Savepoint(ctx, "a.b")
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "cannot be qualified") {
+		t.Errorf("want a qualified savepoint name refused got %v", err)
+	}
+}
@@ -2,17 +2,91 @@ package grepo
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
+	"net/url"
 	"os"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 )
 
+// SQLiteConnector opens SQLite databases with the mattn/go-sqlite3 driver. That
+// driver honours context cancellation by calling sqlite3_interrupt on the
+// connection, so a cancelled or expired context aborts a running query or
+// statement. No build tags are needed for this, but the driver requires cgo.
 type SQLiteConnector struct {
-	path string
+	path    string
+	options sqliteOptions
+	// temp is the path of the temporary copy made WithTempCopy, removed by Close
+	temp string
+	dbs  []*sql.DB
+	mu   sync.Mutex
 }
 
-func NewSQLiteConnector(path string) (*SQLiteConnector, error) {
+// SQLiteOption configures the PRAGMA tuning applied when a SQLiteConnector
+// opens the database. All options are off by default.
+type SQLiteOption func(*sqliteOptions)
+
+type sqliteOptions struct {
+	wal         bool
+	busyTimeout time.Duration
+	foreignKeys bool
+	tempCopy    bool
+}
+
+// WithWAL opens the database with journal_mode=WAL, which allows readers to
+// proceed while a writer is active.
+func WithWAL() SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.wal = true
+	}
+}
+
+// WithBusyTimeout sets how long a connection waits on a locked database before
+// failing with SQLITE_BUSY.
+func WithBusyTimeout(d time.Duration) SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.busyTimeout = d
+	}
+}
+
+// WithForeignKeys enables foreign key enforcement, which SQLite leaves off by default.
+func WithForeignKeys() SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.foreignKeys = true
+	}
+}
+
+// WithTempCopy opens a temporary copy of the database file rather than the file
+// itself, so changes are discarded. The copy is removed by Close.
+func WithTempCopy() SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.tempCopy = true
+	}
+}
+
+// NewSQLiteConnector returns a connector for the database file at path, which
+// must exist, or for an in-memory database when path is :memory: or a URI such
+// as file::memory:?cache=shared.
+func NewSQLiteConnector(path string, opts ...SQLiteOption) (*SQLiteConnector, error) {
+	o := sqliteOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if isMemory(path) {
+		if o.tempCopy {
+			return nil, fmt.Errorf("an in-memory database %s cannot be opened WithTempCopy", path)
+		}
+		return &SQLiteConnector{
+			path:    path,
+			options: o,
+		}, nil
+	}
+
 	_, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -20,25 +94,140 @@ func NewSQLiteConnector(path string) (*SQLiteConnector, error) {
 		}
 		return nil, err
 	}
-	return &SQLiteConnector{
-		path,
-	}, nil
+
+	c := &SQLiteConnector{
+		path:    path,
+		options: o,
+	}
+
+	if o.tempCopy {
+		if err = c.copyToTemp(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// isMemory reports whether path names an in-memory database rather than a file.
+func isMemory(path string) bool {
+	return path == ":memory:" ||
+		strings.HasPrefix(path, "file::memory:") ||
+		strings.Contains(path, "mode=memory")
+}
+
+// copyToTemp copies the database file to a temporary file and points the
+// connector at the copy.
+func (c *SQLiteConnector) copyToTemp() error {
+	input, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to read database file %s: %w", c.path, err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "grepo-sqlite-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	_ = tmpFile.Close()
+
+	if err = os.WriteFile(tmpFile.Name(), input, 0600); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return fmt.Errorf("failed to write temp database: %w", err)
+	}
+
+	c.path = tmpFile.Name()
+	c.temp = tmpFile.Name()
+	return nil
+}
+
+// dsn appends the connection parameters understood by go-sqlite3 for any
+// configured options to the database path.
+func (c *SQLiteConnector) dsn() string {
+	params := url.Values{}
+
+	if c.options.wal {
+		params.Set("_journal_mode", "WAL")
+	}
+	if c.options.busyTimeout > 0 {
+		params.Set("_busy_timeout", fmt.Sprintf("%d", c.options.busyTimeout.Milliseconds()))
+	}
+	if c.options.foreignKeys {
+		params.Set("_foreign_keys", "on")
+	}
+
+	if len(params) == 0 {
+		return c.path
+	}
+
+	separator := "?"
+	if strings.Contains(c.path, "?") {
+		separator = "&"
+	}
+
+	return c.path + separator + params.Encode()
 }
 
-// GetConnection currently returns a temporary copy and will be removed
-// when the program terminates.
+// GetConnection opens a new handle on the database, or on its temporary copy
+// when created WithTempCopy. Every handle is closed by Close. An in-memory
+// database only lives as long as its connection, so it is opened once, limited
+// to that single connection, and the same handle is returned on subsequent
+// calls.
 func (c *SQLiteConnector) GetConnection() (*sql.DB, error) {
-	// Open the temporary database
-	db, err := sql.Open("sqlite3", c.path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	memory := isMemory(c.path)
+	if memory && len(c.dbs) > 0 {
+		return c.dbs[0], nil
+	}
+
+	db, err := sql.Open("sqlite3", c.dsn())
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database file: %w", err)
 	}
 
-	// Clean up the temporary file when the database is closed
+	if memory {
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+	}
+
+	// Close is the way to release the handle, this is only a safety net for
+	// handles which are dropped without it.
 	runtime.SetFinalizer(db, func(db *sql.DB) {
 		_ = db.Close()
 	})
 
+	c.dbs = append(c.dbs, db)
+
 	return db, nil
 }
+
+// Close closes every handle returned by GetConnection and removes the temporary
+// copy of the database, if one was made.
+func (c *SQLiteConnector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for _, db := range c.dbs {
+		if err := db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.dbs = nil
+
+	if c.temp != "" {
+		// WAL mode leaves its own files next to the database
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Remove(c.temp + suffix); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+		c.temp = ""
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,9 +1,15 @@
 package grepo
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestSQLiteConnector(t *testing.T) {
@@ -29,3 +35,185 @@ func TestSQLiteConnector(t *testing.T) {
 	}
 
 }
+
+func TestSQLiteConnectorWAL(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	file, err := createTempDatabase(filepath.Join(filepath.Dir(filename), "test_files", "chinook.sqlite"))
+
+	if err != nil {
+		t.Fatalf("failed to create temp database %v", err)
+	}
+
+	defer func() {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			_ = os.Remove(file.Name() + suffix)
+		}
+	}()
+
+	c, err := NewSQLiteConnector(file.Name(), WithWAL(), WithBusyTimeout(5*time.Second), WithForeignKeys())
+
+	if err != nil {
+		t.Fatalf("connector failed %v", err)
+	}
+
+	first, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("connector failed to open the database %v", err)
+	}
+	defer func() { _ = first.Close() }()
+
+	second, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("connector failed to open the database %v", err)
+	}
+	defer func() { _ = second.Close() }()
+
+	var mode string
+	if err = first.QueryRow("pragma journal_mode").Scan(&mode); err != nil {
+		t.Fatalf("failed to read journal mode %v", err)
+	}
+
+	if mode != "wal" {
+		t.Errorf("want journal mode wal got %s", mode)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+
+	for _, db := range []*sql.DB{first, second} {
+		wg.Add(1)
+		go func(db *sql.DB) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := db.Exec(`insert into Artist ("name") values ($1)`, "grepo-wal"); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(db)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent write failed %v", err)
+	}
+
+	var count int64
+	if err = first.QueryRow(`select count(*) from Artist where Name = 'grepo-wal'`).Scan(&count); err != nil {
+		t.Fatalf("failed to count rows %v", err)
+	}
+
+	if count != 100 {
+		t.Errorf("want 100 rows got %d", count)
+	}
+}
+
+func TestSQLiteConnectorClose(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	testDatabase := filepath.Join(filepath.Dir(filename), "test_files", "chinook.sqlite")
+
+	c, err := NewSQLiteConnector(testDatabase, WithTempCopy())
+	if err != nil {
+		t.Fatalf("connector failed %v", err)
+	}
+
+	temp := c.temp
+	if _, err = os.Stat(temp); err != nil {
+		t.Fatalf("want a temp copy of the database got %v", err)
+	}
+
+	db, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("connector failed to open the database %v", err)
+	}
+
+	if _, err = db.Exec(`insert into Artist ("name") values ($1)`, "grepo-temp"); err != nil {
+		t.Fatalf("failed to write to the temp copy %v", err)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Fatalf("failed to close connector %v", err)
+	}
+
+	if err = db.Ping(); err == nil {
+		t.Errorf("want the handle closed after Close")
+	}
+
+	if _, err = os.Stat(temp); !os.IsNotExist(err) {
+		t.Errorf("want the temp copy removed after Close got %v", err)
+	}
+}
+
+func TestSQLiteConnectorMemory(t *testing.T) {
+	for _, path := range []string{":memory:", "file::memory:?cache=shared"} {
+		t.Run(path, func(t *testing.T) {
+			c, err := NewSQLiteConnector(path)
+			if err != nil {
+				t.Fatalf("connector failed %v", err)
+			}
+			defer func() { _ = c.Close() }()
+
+			db, err := c.GetConnection()
+			if err != nil {
+				t.Fatalf("connector failed to open the database %v", err)
+			}
+
+			if _, err = db.Exec(`create table Genre (GenreId integer primary key, Name text)`); err != nil {
+				t.Fatalf("failed to create table %v", err)
+			}
+
+			again, err := c.GetConnection()
+			if err != nil || again != db {
+				t.Fatalf("want the same handle on subsequent calls got %v", err)
+			}
+
+			genres := NewRepository[string](again)
+			if _, err = genres.Execute(context.Background(), `insert into Genre (Name) values ($1)`, []any{"Rock"}); err != nil {
+				t.Fatalf("failed to insert row %v", err)
+			}
+
+			name, err := genres.MapRow(context.Background(), `select Name from Genre`, nil, Simple(func(r *RowMap) *string {
+				name := r.String("Name")
+				return &name
+			}))
+
+			if err != nil || name == nil || *name != "Rock" {
+				t.Errorf("want Rock got %v and %v", name, err)
+			}
+		})
+	}
+}
+
+func TestSQLiteConnectorInterrupt(t *testing.T) {
+	c, err := NewSQLiteConnector(":memory:")
+	if err != nil {
+		t.Fatalf("connector failed %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	db, err := c.GetConnection()
+	if err != nil {
+		t.Fatalf("connector failed to open the database %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = NewRepository[Album](db).MapRows(ctx, slowQuery, nil, albumIdMapper)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context canceled got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("want the query interrupted promptly, it took %v", elapsed)
+	}
+
+	// the interrupted connection is still usable
+	if err = db.Ping(); err != nil {
+		t.Errorf("want the connection usable after the interrupt got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package grepo
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// StructMapper returns a MapFunc which maps a row into the exported fields of
+// the struct T by column name, for queries whose columns line up with a struct
+// closely enough that writing a mapper by hand is busywork. A field is matched
+// by its db tag when it has one, db:"-" skips the field, otherwise by its name
+// ignoring case and underscores, so the columns artist_id, ArtistID and
+// artistId all map into a field named ArtistID. Columns without a field are
+// ignored and SQL NULL leaves the field at its zero value. Fields which are
+// sql.Scanner are passed the raw value.
+func StructMapper[T any]() MapFunc[T] {
+	fields, err := structFields(reflect.TypeFor[T]())
+
+	return func(r *RowMap) (*T, error) {
+		if err != nil {
+			return nil, err
+		}
+
+		t := new(T)
+		rv := reflect.ValueOf(t).Elem()
+
+		for _, col := range r.cols {
+			index, ok := fields[col]
+			if !ok {
+				index, ok = fields[normalizeName(col)]
+			}
+			if !ok {
+				continue
+			}
+
+			if err := assign(rv.Field(index), r.m[col]); err != nil {
+				r.addErr(fmt.Errorf("cannot map column '%s' into field %s: %w", col, rv.Type().Field(index).Name, err))
+			}
+		}
+
+		if err := r.Err(); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+}
+
+// structFields indexes the exported fields of t by their db tag, used as is, or
+// by their normalized name.
+func structFields(t reflect.Type) (map[string]int, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("StructMapper requires a struct type, %v is a %v", t, t.Kind())
+	}
+
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		if tag, ok := f.Tag.Lookup("db"); ok {
+			if tag != "-" {
+				fields[tag] = i
+			}
+			continue
+		}
+
+		fields[normalizeName(f.Name)] = i
+	}
+
+	return fields, nil
+}
+
+// normalizeName lowercases name and drops underscores, so snake_case, camelCase
+// and CamelCase spellings of the same words compare equal.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+var scannerType = reflect.TypeFor[sql.Scanner]()
+
+// assign sets field from a value returned by the driver, converting between
+// the integer, float and string kinds the drivers use.
+func assign(field reflect.Value, v any) error {
+	if field.Addr().Type().Implements(scannerType) {
+		return field.Addr().Interface().(sql.Scanner).Scan(v)
+	}
+
+	if v == nil {
+		field.SetZero()
+		return nil
+	}
+
+	// a streamed column's buffer is reused by the next row
+	if raw, ok := v.(sql.RawBytes); ok {
+		v = bytes.Clone(raw)
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Type().AssignableTo(field.Type()) {
+		field.Set(val)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInteger64(v)
+		if err != nil {
+			return err
+		}
+		if field.OverflowInt(i) {
+			return fmt.Errorf("value %d overflows %v", i, field.Type())
+		}
+		field.SetInt(i)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat[float64](v)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+		return nil
+	case reflect.String:
+		if b, ok := v.([]byte); ok {
+			field.SetString(string(b))
+			return nil
+		}
+	case reflect.Bool:
+		if i, err := toInteger64(v); err == nil {
+			field.SetBool(i != 0)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot convert %v (%T) to %v", v, v, field.Type())
+}
+
+// structArgs builds the named args for MapRowsNStruct from the exported fields
+// of entity, a struct or pointer to one, keyed by ":" and their db tag or, when
+// untagged, their name. Fields tagged db:"-" are skipped.
+func structArgs(entity any) (map[string]any, error) {
+	rv := reflect.ValueOf(entity)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("cannot bind the fields of a nil %v", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot bind the fields of %v, it is not a struct", rv.Type())
+	}
+
+	args := make(map[string]any, rv.NumField())
+	for i := range rv.NumField() {
+		f := rv.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("db"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+
+		args[":"+name] = rv.Field(i).Interface()
+	}
+
+	return args, nil
+}
@@ -0,0 +1,90 @@
+package grepo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestStructMapper(t *testing.T) {
+	results, err := albums.MapRows(
+		context.Background(),
+		"select AlbumId as album_id, ArtistId as artist_id, Title as title from Album where AlbumId = $1",
+		[]any{1},
+		StructMapper[Album]())
+
+	if err != nil {
+		t.Fatalf("error mapping struct %v", err)
+	}
+
+	want := Album{AlbumID: 1, ArtistID: 1, Title: "For Those About To Rock We Salute You"}
+	if len(results) != 1 || *results[0] != want {
+		t.Errorf("want %v got %v", want, results)
+	}
+}
+
+func TestStructMapperTags(t *testing.T) {
+	type track struct {
+		ID       int64          `db:"TrackId"`
+		Name     string         `db:"Name"`
+		Composer sql.NullString `db:"Composer"`
+		Skipped  string         `db:"-"`
+		Price    float64        `db:"UnitPrice"`
+	}
+
+	results, err := NewRepository[track](testDB).MapRows(
+		context.Background(),
+		"select TrackId, Name, Composer, UnitPrice, Name as Skipped from Track where TrackId in ( $1 )",
+		[]any{[]int{1, 63}},
+		StructMapper[track]())
+
+	if err != nil {
+		t.Fatalf("error mapping struct %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("want 2 tracks got %d", len(results))
+	}
+
+	if results[0].ID != 1 || results[0].Name == "" || !results[0].Composer.Valid || results[0].Price != 0.99 {
+		t.Errorf("want track 1 mapped got %+v", results[0])
+	}
+
+	if results[1].Composer.Valid || results[1].Skipped != "" {
+		t.Errorf("want a NULL composer and no Skipped got %+v", results[1])
+	}
+}
+
+func TestStructMapperNotStruct(t *testing.T) {
+	_, err := NewRepository[int](testDB).MapRows(context.Background(), "select 1", nil, StructMapper[int]())
+
+	if err == nil {
+		t.Errorf("want error mapping into a non-struct got nil")
+	}
+}
+
+func TestMapRowsNStruct(t *testing.T) {
+	type Artist struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+		Note string `db:"-"`
+	}
+
+	results, err := albums.MapRowsNStruct(
+		context.Background(),
+		"select a.AlbumId, a.Title, a.ArtistId from Album a join Artist r on r.ArtistId = a.ArtistId where r.Name = :name",
+		&Artist{Name: "AC/DC"},
+		albumMapper)
+
+	if err != nil {
+		t.Fatalf("error retrieving rows %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Errorf("want 2 albums by AC/DC got %d", len(results))
+	}
+
+	if _, err = albums.MapRowsNStruct(context.Background(), "select 1", 1, albumMapper); err == nil {
+		t.Errorf("want error binding a non-struct got nil")
+	}
+}